@@ -0,0 +1,43 @@
+package emailtemplates
+
+import (
+	"net/mail"
+	"strings"
+	"unicode"
+)
+
+// defaultSenderNames are the display names of the sender of the built in emails sent on behalf of an
+// organization, other emails are sent from FromName or the company name
+var defaultSenderNames = map[string]string{
+	"trust_center_auth":        "{{ .OrganizationName }} Trust Center via {{ .EmailData.CompanyName }}",
+	"trust_center_nda_request": "{{ .OrganizationName }} Trust Center via {{ .EmailData.CompanyName }}",
+	"trust_center_nda_signed":  "{{ .OrganizationName }} Trust Center via {{ .EmailData.CompanyName }}",
+	"questionnaire_auth":       "{{ .CompanyName }} via {{ .EmailData.CompanyName }}",
+}
+
+// FormatAddress returns the address with the display name as an RFC 5322 address, the name is
+// quoted or RFC 2047 encoded when needed. The bare address is returned when the name is empty
+func FormatAddress(name, address string) string {
+	name = strings.Join(strings.FieldsFunc(name, unicode.IsControl), " ")
+	name = strings.Join(strings.Fields(name), " ")
+
+	if name == "" {
+		return address
+	}
+
+	return (&mail.Address{Name: name, Address: address}).String()
+}
+
+// Name returns the full name of the recipient
+func (r Recipient) Name() string {
+	return strings.TrimSpace(r.FirstName + " " + r.LastName)
+}
+
+// addresses returns the from and to addresses of the message, with display names when they are enabled
+func (e EmailData) addresses() (from, to string) {
+	if !e.DisplayNames {
+		return e.FromEmail, e.Recipient.Email
+	}
+
+	return FormatAddress(valueOr(e.FromName, e.CompanyName), e.FromEmail), FormatAddress(e.Recipient.Name(), e.Recipient.Email)
+}
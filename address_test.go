@@ -0,0 +1,77 @@
+package emailtemplates
+
+import (
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		display  string
+		expected string
+	}{
+		{name: "empty name", display: "", expected: "user@example.com"},
+		{name: "plain name", display: "Meow Meowington", expected: `"Meow Meowington" <user@example.com>`},
+		{name: "comma", display: "Meowington, Meow", expected: `"Meowington, Meow" <user@example.com>`},
+		{name: "quotes", display: `Meow "Kitty" Meowington`, expected: `"Meow \"Kitty\" Meowington" <user@example.com>`},
+		{name: "non ascii", display: "Chloé Müller", expected: "=?utf-8?q?Chlo=C3=A9_M=C3=BCller?= <user@example.com>"},
+		{name: "header injection", display: "Meow\r\nBcc: evil@example.com", expected: `"Meow Bcc: evil@example.com" <user@example.com>`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			address := FormatAddress(tc.display, "user@example.com")
+			assert.Equal(t, tc.expected, address)
+
+			parsed, err := mail.ParseAddress(address)
+			require.NoError(t, err)
+			assert.Equal(t, "user@example.com", parsed.Address)
+		})
+	}
+}
+
+func TestDisplayNamesInEmail(t *testing.T) {
+	cfg := categoryConfig()
+
+	email, err := cfg.NewWelcomeEmail(Recipient{Email: "test@example.com", FirstName: "Meow", LastName: "Meowington"})
+	require.NoError(t, err)
+	assert.Equal(t, cfg.FromEmail, email.From)
+	assert.Equal(t, []string{"test@example.com"}, email.To)
+
+	WithDisplayNames("")(&cfg)
+
+	email, err = cfg.NewWelcomeEmail(Recipient{Email: "test@example.com", FirstName: "Meow", LastName: "Meowington"})
+	require.NoError(t, err)
+	assert.Equal(t, FormatAddress(cfg.CompanyName, cfg.FromEmail), email.From)
+	assert.Equal(t, []string{`"Meow Meowington" <test@example.com>`}, email.To)
+
+	// recipients without a name are addressed by email only
+	email, err = cfg.NewTrustCenterAuthEmail(Recipient{Email: "test@example.com"}, "token", TrustCenterAuthData{
+		OrganizationName: "Acme",
+		TrustCenterURL:   "https://trust.example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, FormatAddress("Acme Trust Center via "+cfg.CompanyName, cfg.SenderProfiles["security"].FromEmail), email.From)
+	assert.Equal(t, []string{"test@example.com"}, email.To)
+
+	WithSenderName("trust_center_auth", "{{ .OrganizationName }} Security")(&cfg)
+
+	email, err = cfg.NewTrustCenterAuthEmail(Recipient{Email: "test@example.com"}, "token", TrustCenterAuthData{
+		OrganizationName: "Acme, Inc.",
+		TrustCenterURL:   "https://trust.example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `"Acme, Inc. Security" <`+cfg.SenderProfiles["security"].FromEmail+">", email.From)
+
+	WithSenderName("trust_center_auth", "{{ .Missing }}")(&cfg)
+
+	_, err = cfg.NewTrustCenterAuthEmail(Recipient{Email: "test@example.com"}, "token", TrustCenterAuthData{
+		OrganizationName: "Acme",
+		TrustCenterURL:   "https://trust.example.com",
+	})
+	require.Error(t, err)
+}
@@ -27,9 +27,13 @@ var (
 	templates map[string]*template.Template
 	partials  []string
 
-	// Shared function map
+	// Shared function map, ToUpper upper camel cases a value for names, e.g. "tony stark" to "TonyStark",
+	// while AllCaps upper cases every letter, e.g. "staging" to "STAGING"
 	fm = template.FuncMap{
-		"ToUpper": strcase.UpperCamelCase,
+		"ToUpper":          strcase.UpperCamelCase,
+		"AllCaps":          strings.ToUpper,
+		"JoinURL":          JoinURL,
+		"HumanizeDuration": HumanizeDuration,
 	}
 )
 
@@ -50,11 +54,15 @@ func init() {
 			continue
 		}
 
-		templates[file.Name()] = parseTemplate(file.Name())
+		templates[file.Name()], err = parseTemplate(file.Name())
+		if err != nil {
+			log.Fatal().Err(err).Str("template", file.Name()).Msg("could not parse template")
+		}
 	}
 }
 
-func parseTemplate(name string) *template.Template {
+// parseTemplate parses an embedded template along with the embedded partials for its extension
+func parseTemplate(name string) (*template.Template, error) {
 	// Each template will be accessible by its base name in the global map
 	patterns := []string{}
 	patterns = append(patterns, filepath.Join(defaultTemplatesDir, name))
@@ -68,12 +76,7 @@ func parseTemplate(name string) *template.Template {
 		}
 	}
 
-	tmpl, err := template.New(name).Funcs(fm).ParseFS(files, patterns...)
-	if err != nil {
-		log.Fatal().Err(err).Str("template", name).Msg("could not parse template")
-	}
-
-	return tmpl
+	return template.New(name).Funcs(fm).ParseFS(files, patterns...)
 }
 
 // parseCustomTemplate loads a template from the file system
@@ -109,7 +112,12 @@ func Render(name string, data interface{}) (text, html string, err error) {
 
 // render the provided template with the data
 func render(name string, data interface{}) (_ string, err error) {
-	t, ok := templates[name]
+	return renderFrom(templates, name, data)
+}
+
+// renderFrom renders the provided template from the given set of parsed templates
+func renderFrom(set map[string]*template.Template, name string, data interface{}) (_ string, err error) {
+	t, ok := set[name]
 	if !ok {
 		return "", fmt.Errorf("%w: %q not found in templates", ErrMissingTemplate, name)
 	}
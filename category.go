@@ -0,0 +1,62 @@
+package emailtemplates
+
+// CategoryTag is the name of the message tag that holds the category of an email
+const CategoryTag = "category"
+
+// Category is the kind of message an email is, it decides the footer, whether the recipient can
+// unsubscribe, the sender and whether suppressed recipients are skipped
+type Category string
+
+// Categories of the emails
+const (
+	// CategorySecurity emails are required to use the account, e.g. verification and password resets,
+	// they have no unsubscribe link and are sent to suppressed recipients
+	CategorySecurity Category = "security"
+	// CategoryTransactional emails are sent in response to an action, e.g. invites
+	CategoryTransactional Category = "transactional"
+	// CategoryProduct emails are about the product, e.g. onboarding, the recipient can unsubscribe
+	CategoryProduct Category = "product"
+	// CategoryMarketing emails are promotional or subscriptions, the recipient must be able to unsubscribe
+	CategoryMarketing Category = "marketing"
+)
+
+// templateCategories are the categories of the built in emails, other templates are transactional
+var templateCategories = map[string]Category{
+	"verify_email":             CategorySecurity,
+	"password_reset_request":   CategorySecurity,
+	"password_reset_success":   CategorySecurity,
+	"verify_billing":           CategorySecurity,
+	"billing_email_changed":    CategorySecurity,
+	"trust_center_auth":        CategorySecurity,
+	"questionnaire_auth":       CategorySecurity,
+	"invite":                   CategoryTransactional,
+	"invite_joined":            CategoryTransactional,
+	"trust_center_nda_request": CategoryTransactional,
+	"trust_center_nda_signed":  CategoryTransactional,
+	"welcome":                  CategoryProduct,
+	"subscribe":                CategoryMarketing,
+}
+
+// Unsubscribable reports whether emails of the category get an unsubscribe link and headers
+func (c Category) Unsubscribable() bool {
+	return c == CategoryProduct || c == CategoryMarketing
+}
+
+// Suppressible reports whether emails of the category are skipped for suppressed recipients
+func (c Category) Suppressible() bool {
+	return c != CategorySecurity
+}
+
+// CategoryOf returns the category of the template, configured categories take precedence over the
+// built in ones
+func (c Config) CategoryOf(template string) Category {
+	if category, ok := c.Categories[template]; ok {
+		return category
+	}
+
+	if category, ok := templateCategories[template]; ok {
+		return category
+	}
+
+	return CategoryTransactional
+}
@@ -0,0 +1,86 @@
+package emailtemplates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryOf(t *testing.T) {
+	cfg := Config{Categories: map[string]Category{"newsletter": CategoryMarketing, "welcome": CategoryTransactional}}
+
+	assert.Equal(t, CategorySecurity, cfg.CategoryOf("password_reset_request"))
+	assert.Equal(t, CategoryMarketing, cfg.CategoryOf("subscribe"))
+	assert.Equal(t, CategoryMarketing, cfg.CategoryOf("newsletter"))
+	assert.Equal(t, CategoryTransactional, cfg.CategoryOf("welcome"))
+	assert.Equal(t, CategoryTransactional, cfg.CategoryOf("custom"))
+
+	assert.False(t, CategorySecurity.Unsubscribable())
+	assert.False(t, CategorySecurity.Suppressible())
+	assert.False(t, CategoryTransactional.Unsubscribable())
+	assert.True(t, CategoryTransactional.Suppressible())
+	assert.True(t, CategoryProduct.Unsubscribable())
+	assert.True(t, CategoryMarketing.Unsubscribable())
+}
+
+// categoryConfig returns a config with an unsubscribe URL, a signing key and a sender per category
+func categoryConfig() Config {
+	return Config{
+		CompanyName:  "Test Company",
+		FromEmail:    "no-reply@example.com",
+		SupportEmail: "support@example.com",
+		URLS: URLConfig{
+			Root:             "https://www.example.com",
+			Product:          "https://console.example.com",
+			Verify:           "https://console.example.com/verify?token=abc",
+			VerifySubscriber: "https://console.example.com/subscriber-verify?token=abc",
+			Unsubscribe:      "https://console.example.com/unsubscribe",
+		},
+		SignedURLs: SignedURLConfig{KeyID: "v1", Keys: map[string]string{"v1": "secret"}},
+		SenderProfiles: map[string]SenderProfile{
+			"security":  {FromEmail: "security@example.com", Categories: []Category{CategorySecurity}},
+			"marketing": {FromEmail: "news@example.com", Categories: []Category{CategoryMarketing}},
+		},
+	}
+}
+
+func TestCategoryInEmail(t *testing.T) {
+	marketing, err := subscribe(SubscriberEmailData{
+		EmailData:        EmailData{Config: categoryConfig(), Recipient: Recipient{Email: "test@example.com"}},
+		OrganizationName: "Meow Inc.",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "marketing", tagValue(marketing, CategoryTag))
+	assert.Equal(t, "news@example.com", marketing.From)
+	assert.NotEmpty(t, marketing.Headers[ListUnsubscribeHeader])
+	assert.Contains(t, marketing.Text, "Unsubscribe https://console.example.com/unsubscribe?token=")
+	assert.Contains(t, marketing.Text, "You are receiving this email because")
+
+	security, err := verify(VerifyEmailData{
+		EmailData: EmailData{Config: categoryConfig(), Recipient: Recipient{Email: "test@example.com"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "security", tagValue(security, CategoryTag))
+	assert.Equal(t, "security@example.com", security.From)
+	assert.Empty(t, security.Headers)
+	assert.NotContains(t, security.Text, "Unsubscribe")
+	assert.NotContains(t, security.HTML, "unsubscribe")
+	assert.NotContains(t, security.Text, "You are receiving this email because")
+}
+
+func TestQuestionnaireSenderPrecedence(t *testing.T) {
+	cfg := categoryConfig()
+	cfg.QuestionnaireEmail = "questionnaire@example.com"
+
+	e := EmailData{Config: cfg, Template: "questionnaire_auth", Category: CategorySecurity}
+	assert.Equal(t, "questionnaire@example.com", e.sender())
+
+	e.QuestionnaireEmail = ""
+	assert.Equal(t, "security@example.com", e.sender())
+
+	e.Category = CategoryTransactional
+	assert.Equal(t, "no-reply@example.com", e.sender())
+}
@@ -0,0 +1,48 @@
+// Command emailpreview serves a local preview of every email template rendered with sample data
+// so copy and layout changes can be reviewed in a browser without running the tests
+//
+//	go run ./cmd/emailpreview -templates ./templates -addr localhost:8080
+package main
+
+import (
+	"flag"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultAddr         = "localhost:8080"
+	defaultPollInterval = time.Second
+	readHeaderTimeout   = 10 * time.Second
+)
+
+func main() {
+	addr := flag.String("addr", defaultAddr, "address to listen on")
+	path := flag.String("templates", "", "path to a custom templates directory, defaults to the embedded templates")
+	poll := flag.Duration("poll", defaultPollInterval, "interval to check the templates directory for changes")
+
+	flag.Parse()
+
+	srv, err := newServer(*path)
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not load templates")
+	}
+
+	if *path != "" {
+		go srv.watch(*poll)
+	}
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           srv.routes(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	log.Info().Str("addr", "http://"+*addr).Msg("serving email previews")
+
+	if err := httpServer.ListenAndServe(); err != nil {
+		log.Fatal().Err(err).Msg("preview server stopped")
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/theopenlane/emailtemplates"
+)
+
+const (
+	// defaultLocale is the locale name used for the templates at the root of the templates directory
+	defaultLocale = "default"
+	// partialsDir is skipped when looking for locale directories
+	partialsDir = "partials"
+)
+
+var (
+	//go:embed page.html
+	pageFS embed.FS
+	page   = template.Must(template.ParseFS(pageFS, "page.html"))
+
+	// themes are the background styles the rendered html can be previewed against
+	themes = []string{"light", "dark"}
+)
+
+// server renders previews of every template, one template set per locale
+type server struct {
+	path string
+
+	mu      sync.RWMutex
+	sets    map[string]*emailtemplates.TemplateSet
+	locales []string
+	version int
+	changed chan struct{}
+}
+
+// newServer loads the templates at path, every subdirectory other than the partials
+// directory is treated as an additional locale which can be switched to in the preview
+func newServer(path string) (*server, error) {
+	s := &server{
+		path:    path,
+		changed: make(chan struct{}),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// load (re)parses all template sets and notifies any open previews
+func (s *server) load() error {
+	sets := map[string]*emailtemplates.TemplateSet{}
+
+	set, err := emailtemplates.LoadTemplateSet(s.path)
+	if err != nil {
+		return err
+	}
+
+	sets[defaultLocale] = set
+	locales := []string{defaultLocale}
+
+	if s.path != "" {
+		if err := emailtemplates.LoadSamples(s.path); err != nil {
+			return err
+		}
+
+		entries, err := os.ReadDir(s.path)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || entry.Name() == partialsDir || !hasTemplates(filepath.Join(s.path, entry.Name())) {
+				continue
+			}
+
+			set, err := emailtemplates.LoadTemplateSet(filepath.Join(s.path, entry.Name()))
+			if err != nil {
+				return fmt.Errorf("could not load locale %q: %w", entry.Name(), err)
+			}
+
+			sets[entry.Name()] = set
+			locales = append(locales, entry.Name())
+		}
+	}
+
+	sort.Strings(locales[1:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sets = sets
+	s.locales = locales
+	s.version++
+
+	// wake up everyone waiting on a change and start a new generation
+	close(s.changed)
+	s.changed = make(chan struct{})
+
+	return nil
+}
+
+// hasTemplates reports whether dir contains at least one template with both a text and html version
+func hasTemplates(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.html"))
+
+	for _, match := range matches {
+		if _, err := os.Stat(strings.TrimSuffix(match, ".html") + ".txt"); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watch polls the templates directory and reloads the templates whenever a file changes
+func (s *server) watch(interval time.Duration) {
+	last := s.lastModified()
+
+	for range time.Tick(interval) {
+		current := s.lastModified()
+		if current.Equal(last) {
+			continue
+		}
+
+		last = current
+
+		if err := s.load(); err != nil {
+			log.Error().Err(err).Msg("could not reload templates, keeping the previous version")
+			continue
+		}
+
+		log.Info().Msg("templates reloaded")
+	}
+}
+
+// lastModified returns the most recent modification time of any file in the templates directory
+func (s *server) lastModified() (latest time.Time) {
+	_ = filepath.WalkDir(s.path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+
+		return nil
+	})
+
+	return latest
+}
+
+// routes returns the handler for the preview server
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /preview/{name}", s.handlePreview)
+	mux.HandleFunc("GET /render/{name}", s.handleRender)
+	mux.HandleFunc("GET /events", s.handleEvents)
+
+	return mux
+}
+
+// preview is the data used to render the preview page
+type preview struct {
+	Names   []string
+	Locales []string
+	Themes  []string
+	Samples []emailtemplates.Sample
+	Locale  string
+	Theme   string
+	Sample  string
+	Version int
+
+	Name    string
+	Subject string
+	Text    string
+	Error   string
+}
+
+// newPreview returns the page data for the locale and theme requested
+func (s *server) newPreview(r *http.Request) (preview, *emailtemplates.TemplateSet) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p := preview{
+		Locales: s.locales,
+		Themes:  themes,
+		Locale:  r.URL.Query().Get("locale"),
+		Theme:   r.URL.Query().Get("theme"),
+		Sample:  r.URL.Query().Get("sample"),
+		Version: s.version,
+	}
+
+	set, ok := s.sets[p.Locale]
+	if !ok {
+		p.Locale = defaultLocale
+		set = s.sets[defaultLocale]
+	}
+
+	if p.Theme == "" {
+		p.Theme = themes[0]
+	}
+
+	if p.Sample == "" {
+		p.Sample = emailtemplates.DefaultSample
+	}
+
+	p.Names = set.Names()
+
+	return p, set
+}
+
+// handleIndex lists every template available
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	p, _ := s.newPreview(r)
+
+	s.write(w, p)
+}
+
+// handlePreview shows the subject, text and html of a single template side by side
+func (s *server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	p, set := s.newPreview(r)
+	p.Name = r.PathValue("name")
+
+	samples, err := emailtemplates.Samples(p.Name)
+	if err != nil {
+		p.Error = err.Error()
+		s.write(w, p)
+
+		return
+	}
+
+	p.Samples = samples
+
+	data, err := emailtemplates.SampleData(p.Name, p.Sample)
+	if err != nil {
+		p.Error = err.Error()
+		s.write(w, p)
+
+		return
+	}
+
+	p.Subject = emailtemplates.Subject(p.Name, data)
+
+	text, _, err := set.Render(p.Name, data)
+	if err != nil {
+		p.Error = err.Error()
+	}
+
+	p.Text = text
+
+	s.write(w, p)
+}
+
+// handleRender returns the rendered html of a template to be displayed in the preview frame
+func (s *server) handleRender(w http.ResponseWriter, r *http.Request) {
+	p, set := s.newPreview(r)
+
+	data, err := emailtemplates.SampleData(r.PathValue("name"), p.Sample)
+	if errors.Is(err, emailtemplates.ErrSampleNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, html, err := set.Render(r.PathValue("name"), data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	_, _ = w.Write([]byte(html))
+}
+
+// handleEvents streams a reload event to the browser whenever the templates change
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	for {
+		s.mu.RLock()
+		changed := s.changed
+		s.mu.RUnlock()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+			if _, err := fmt.Fprint(w, "event: reload\ndata: {}\n\n"); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// write renders the preview page
+func (s *server) write(w http.ResponseWriter, p preview) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if err := page.Execute(w, p); err != nil {
+		log.Error().Err(err).Msg("could not render preview page")
+	}
+}
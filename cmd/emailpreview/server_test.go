@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	srv, err := newServer("")
+	require.NoError(t, err)
+
+	handler := srv.routes()
+
+	t.Run("index lists templates", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "/preview/welcome")
+	})
+
+	t.Run("preview shows subject and text", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/preview/invite?theme=dark", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Join Your Teammate Sarah Funk on Openlane!")
+		assert.Contains(t, rec.Body.String(), "theme-dark")
+	})
+
+	t.Run("render returns html", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/render/verify_email", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "<!doctype html>")
+	})
+
+	t.Run("render unknown template", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/render/nope", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestServerLocales(t *testing.T) {
+	srv, err := newServer("../../testdata")
+	require.NoError(t, err)
+
+	// testdata/expected only contains html files so it is not a locale
+	assert.Equal(t, []string{defaultLocale}, srv.locales)
+}
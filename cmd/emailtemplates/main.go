@@ -0,0 +1,111 @@
+// Command emailtemplates provides tooling for working with custom email templates
+//
+//	emailtemplates lint [-format json|text] <dir>
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/theopenlane/emailtemplates"
+	"github.com/theopenlane/emailtemplates/lint"
+)
+
+const (
+	exitOK = iota
+	exitIssues
+	exitUsage
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "lint":
+		return runLint(args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		usage(stdout)
+		return exitOK
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+	usage(stderr)
+
+	return exitUsage
+}
+
+// usage prints the available commands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: emailtemplates <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  lint [-format json|text] <dir>  check a custom templates directory for problems")
+}
+
+// runLint lints the templates directory, exiting with a non zero code when errors are found
+func runLint(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	format := fs.String("format", "json", "output format, json or text")
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: emailtemplates lint [-format json|text] <dir>")
+		return exitUsage
+	}
+
+	dir := fs.Arg(0)
+
+	// custom samples are used to check the field references of custom templates
+	if err := emailtemplates.LoadSamples(dir); err != nil {
+		fmt.Fprintf(stderr, "could not load samples: %v\n", err)
+		return exitUsage
+	}
+
+	issues, err := lint.Lint(dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not lint %q: %v\n", dir, err)
+		return exitUsage
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(struct {
+			Issues []lint.Issue `json:"issues"`
+		}{Issues: issues}); err != nil {
+			fmt.Fprintf(stderr, "could not write issues: %v\n", err)
+			return exitUsage
+		}
+	case "text":
+		for _, issue := range issues {
+			fmt.Fprintln(stdout, issue.String())
+		}
+	default:
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return exitUsage
+	}
+
+	if lint.HasErrors(issues) {
+		return exitIssues
+	}
+
+	return exitOK
+}
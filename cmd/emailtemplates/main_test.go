@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLint(t *testing.T) {
+	t.Run("clean directory", func(t *testing.T) {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+		assert.Equal(t, exitOK, run([]string{"lint", "../../lint/testdata/clean"}, stdout, stderr))
+
+		var out struct {
+			Issues []map[string]interface{} `json:"issues"`
+		}
+
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &out))
+		assert.Empty(t, out.Issues)
+	})
+
+	t.Run("directory with errors", func(t *testing.T) {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+		assert.Equal(t, exitIssues, run([]string{"lint", "-format", "text", "../../lint/testdata/broken"}, stdout, stderr))
+		assert.Contains(t, stdout.String(), "(missing-partial)")
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+		assert.Equal(t, exitUsage, run(nil, stdout, stderr))
+		assert.Equal(t, exitUsage, run([]string{"lint"}, stdout, stderr))
+		assert.Equal(t, exitUsage, run([]string{"lint", "-format", "xml", "../../templates"}, stdout, stderr))
+		assert.Equal(t, exitUsage, run([]string{"fmt"}, stdout, stderr))
+		assert.Equal(t, exitOK, run([]string{"help"}, stdout, stderr))
+	})
+}
@@ -0,0 +1,57 @@
+package emailtemplates
+
+import (
+	"reflect"
+	"strings"
+)
+
+// domainInherit marks the fields derived from the domain with WithDomain
+const domainInherit = "inherit"
+
+// WithDomain fills the unset fields tagged with domain:"inherit" from the domain, the value is the
+// domainPrefix tag, a dot unless the prefix ends with @, the domain and the domainSuffix tag, e.g.
+// https://console.example.com/verify. Fields that are already set are left alone
+func WithDomain(domain string) Option {
+	return func(c *Config) {
+		if domain == "" {
+			return
+		}
+
+		setDomainFields(reflect.ValueOf(c).Elem(), domain)
+	}
+}
+
+// FromDomain creates a new email config with the options, and fills the fields the options left unset
+// from the domain with WithDomain
+func FromDomain(domain string, options ...Option) (*Config, error) {
+	return New(append(options, WithDomain(domain))...)
+}
+
+// setDomainFields sets the empty string fields of the struct tagged with domain:"inherit", walking nested structs
+func setDomainFields(v reflect.Value, domain string) {
+	t := v.Type()
+
+	for i := range t.NumField() {
+		field, value := t.Field(i), v.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		switch {
+		case value.Kind() == reflect.Struct:
+			setDomainFields(value, domain)
+		case value.Kind() == reflect.String && field.Tag.Get("domain") == domainInherit && value.String() == "":
+			value.SetString(domainValue(field.Tag.Get("domainPrefix"), domain, field.Tag.Get("domainSuffix")))
+		}
+	}
+}
+
+// domainValue joins the prefix, domain and suffix, with a dot after the prefix unless it is empty or ends with @
+func domainValue(prefix, domain, suffix string) string {
+	if prefix != "" && !strings.HasSuffix(prefix, "@") {
+		prefix += "."
+	}
+
+	return prefix + domain + suffix
+}
@@ -0,0 +1,54 @@
+package emailtemplates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainValue(t *testing.T) {
+	assert.Equal(t, "no-reply@mail.example.com", domainValue("no-reply@mail", "example.com", ""))
+	assert.Equal(t, "support@example.com", domainValue("support@", "example.com", ""))
+	assert.Equal(t, "https://console.example.com/verify", domainValue("https://console", "example.com", "/verify"))
+	assert.Equal(t, "example.com", domainValue("", "example.com", ""))
+}
+
+func TestWithDomain(t *testing.T) {
+	cfg, err := New(
+		WithCompanyName("Example"),
+		WithCompanyAddress("1 Example Way"),
+		WithDomain("example.com"),
+		WithVerifyURL("https://app.example.com/verify"),
+		WithSupportEmail("help@example.com"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "no-reply@mail.example.com", cfg.FromEmail)
+	assert.Equal(t, "help@example.com", cfg.SupportEmail)
+	assert.Equal(t, "questionnaire@example.com", cfg.QuestionnaireEmail)
+	assert.Equal(t, "https://www.example.com", cfg.URLS.Root)
+	assert.Equal(t, "https://console.example.com", cfg.URLS.Product)
+	assert.Equal(t, "https://docs.example.com", cfg.URLS.Docs)
+	assert.Equal(t, "https://app.example.com/verify", cfg.URLS.Verify)
+	assert.Equal(t, "https://console.example.com/invite", cfg.URLS.Invite)
+	assert.Equal(t, "https://console.example.com/password-reset", cfg.URLS.PasswordReset)
+	assert.Equal(t, "https://www.example.com/legal/privacy", cfg.URLS.Privacy)
+	assert.Equal(t, "https://console.example.com/unsubscribe", cfg.URLS.Unsubscribe)
+
+	// untagged fields are left alone
+	assert.Empty(t, cfg.Corporation)
+	assert.Empty(t, cfg.Tracking.ClickURL)
+}
+
+func TestFromDomain(t *testing.T) {
+	cfg, err := FromDomain("example.com", WithCompanyName("Example"), WithCompanyAddress("1 Example Way"), WithFromEmail("hello@example.com"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello@example.com", cfg.FromEmail)
+	assert.Equal(t, "https://console.example.com/verify", cfg.URLS.Verify)
+
+	cfg, err = FromDomain("", WithCompanyName("Example"), WithCompanyAddress("1 Example Way"), WithFromEmail("hello@example.com"))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.URLS.Verify)
+}
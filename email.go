@@ -3,14 +3,15 @@ package emailtemplates
 import (
 	"io"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/theopenlane/newman"
 )
 
 // NewVerifyEmail returns a new email message based on the config values and the provided recipient and token
-func (c Config) NewVerifyEmail(r Recipient, token string) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewVerifyEmail(r Recipient, token string, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("verify_email"); err != nil {
 		return nil, err
 	}
 
@@ -21,9 +22,11 @@ func (c Config) NewVerifyEmail(r Recipient, token string) (*newman.EmailMessage,
 		},
 	}
 
+	data.Apply(opts...)
+
 	var err error
 
-	data.URLS.Verify, err = addTokenToURL(c.URLS.Verify, token)
+	data.URLS.Verify, err = c.actionURL(&data.EmailData, c.URLS.Verify, PurposeVerify, token)
 	if err != nil {
 		return nil, err
 	}
@@ -32,8 +35,8 @@ func (c Config) NewVerifyEmail(r Recipient, token string) (*newman.EmailMessage,
 }
 
 // NewWelcomeEmail returns a new email message based on the config values and the provided recipient and organization name
-func (c Config) NewWelcomeEmail(r Recipient) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewWelcomeEmail(r Recipient, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("welcome"); err != nil {
 		return nil, err
 	}
 
@@ -44,6 +47,8 @@ func (c Config) NewWelcomeEmail(r Recipient) (*newman.EmailMessage, error) {
 		},
 	}
 
+	data.Apply(opts...)
+
 	return welcome(data)
 }
 
@@ -55,8 +60,8 @@ type InviteTemplateData struct {
 }
 
 // NewInviteEmail returns a new email message based on the config values and the provided recipient and invite data
-func (c Config) NewInviteEmail(r Recipient, i InviteTemplateData, token string) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewInviteEmail(r Recipient, i InviteTemplateData, token string, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("invite"); err != nil {
 		return nil, err
 	}
 
@@ -64,9 +69,11 @@ func (c Config) NewInviteEmail(r Recipient, i InviteTemplateData, token string)
 
 	data.Recipient = r
 
+	data.Apply(opts...)
+
 	var err error
 
-	data.URLS.Invite, err = addTokenToURL(c.URLS.Invite, token)
+	data.URLS.Invite, err = c.actionURL(&data.EmailData, c.URLS.Invite, PurposeInvite, token)
 	if err != nil {
 		return nil, err
 	}
@@ -75,13 +82,15 @@ func (c Config) NewInviteEmail(r Recipient, i InviteTemplateData, token string)
 }
 
 // NewInviteEmail returns a new email message based on the config values and the provided recipient and invite data
-func (c Config) NewInviteAcceptedEmail(r Recipient, i InviteTemplateData) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewInviteAcceptedEmail(r Recipient, i InviteTemplateData, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("invite_joined"); err != nil {
 		return nil, err
 	}
 
 	data := c.newInvite(r, i)
 
+	data.Apply(opts...)
+
 	return inviteAccepted(data)
 }
 
@@ -101,8 +110,8 @@ func (c Config) newInvite(r Recipient, i InviteTemplateData) InviteData {
 }
 
 // NewPasswordResetRequestEmail returns a new email message based on the config values and the provided recipient and token
-func (c Config) NewPasswordResetRequestEmail(r Recipient, token string) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewPasswordResetRequestEmail(r Recipient, token string, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("password_reset_request"); err != nil {
 		return nil, err
 	}
 
@@ -113,9 +122,11 @@ func (c Config) NewPasswordResetRequestEmail(r Recipient, token string) (*newman
 		},
 	}
 
+	data.Apply(opts...)
+
 	var err error
 
-	data.URLS.PasswordReset, err = addTokenToURL(c.URLS.PasswordReset, token)
+	data.URLS.PasswordReset, err = c.actionURL(&data.EmailData, c.URLS.PasswordReset, PurposePasswordReset, token)
 	if err != nil {
 		return nil, err
 	}
@@ -124,8 +135,8 @@ func (c Config) NewPasswordResetRequestEmail(r Recipient, token string) (*newman
 }
 
 // NewPasswordResetSuccessEmail returns  a new email message based on the config values and the provided recipient
-func (c Config) NewPasswordResetSuccessEmail(r Recipient) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewPasswordResetSuccessEmail(r Recipient, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("password_reset_success"); err != nil {
 		return nil, err
 	}
 
@@ -136,12 +147,14 @@ func (c Config) NewPasswordResetSuccessEmail(r Recipient) (*newman.EmailMessage,
 		},
 	}
 
+	data.Apply(opts...)
+
 	return passwordResetSuccess(data)
 }
 
 // NewSubscriberEmail returns a new email message based on the config values and the provided recipient, organization name, and token
-func (c Config) NewSubscriberEmail(r Recipient, organizationName, token string) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewSubscriberEmail(r Recipient, organizationName, token string, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("subscribe"); err != nil {
 		return nil, err
 	}
 
@@ -153,9 +166,11 @@ func (c Config) NewSubscriberEmail(r Recipient, organizationName, token string)
 		OrganizationName: organizationName,
 	}
 
+	data.Apply(opts...)
+
 	var err error
 
-	data.URLS.VerifySubscriber, err = addTokenToURL(c.URLS.VerifySubscriber, token)
+	data.URLS.VerifySubscriber, err = c.actionURL(&data.EmailData, c.URLS.VerifySubscriber, PurposeVerifySubscriber, token)
 	if err != nil {
 		return nil, err
 	}
@@ -163,25 +178,88 @@ func (c Config) NewSubscriberEmail(r Recipient, organizationName, token string)
 	return subscribe(data)
 }
 
-// addTokenToURL adds a token to the URL as a query parameter
+// actionURL returns the URL for the action link of an email. The token is added to the URL and, when
+// signed URLs are enabled, the URL is signed for the recipient and purpose to expire with the link,
+// which defaults to the configured TTL; the token is optional in that case. The link is shortened when
+// a Shortener is configured, setting the URL to display on the email data
+func (c Config) actionURL(data *EmailData, baseURL string, purpose Purpose, token string) (string, error) {
+	longURL, err := c.longActionURL(data, baseURL, purpose, token)
+	if err != nil {
+		return "", err
+	}
+
+	href, display := c.shortLinks(data.context(), longURL)
+	if display != href {
+		data.DisplayURL = display
+	}
+
+	data.addActionLinks(longURL, href, display)
+
+	return href, nil
+}
+
+// longActionURL returns the action link with the token and signature, before it is shortened
+func (c Config) longActionURL(data *EmailData, baseURL string, purpose Purpose, token string) (string, error) {
+	if !c.SignedURLs.Enabled {
+		return addTokenToURL(baseURL, token)
+	}
+
+	// the token is optional unless the URL has a placeholder for it
+	if token != "" || strings.Contains(baseURL, TokenPlaceholder) {
+		var err error
+
+		baseURL, err = addTokenToURL(baseURL, token)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// an expiry set with WithLinkExpiry or WithLinkTTL takes precedence over the configured TTL
+	if data.LinkExpiresAt.IsZero() {
+		data.setLinkExpiry(time.Now().Add(c.SignedURLs.ttl()), c.SignedURLs.ttl())
+	}
+
+	return c.SignedURLs.Sign(baseURL, data.Recipient.Email, purpose, data.LinkExpiresAt)
+}
+
+// addTokenToURL adds a token to the URL. When the URL contains the TokenPlaceholder the token replaces
+// it, so it can be placed in a path segment, the fragment or under a different query parameter,
+// otherwise it is added as the token query parameter. Existing query parameters are kept
 func addTokenToURL(baseURL, token string) (string, error) {
 	if token == "" {
 		return "", newMissingRequiredFieldError("token")
 	}
 
+	if i := strings.Index(baseURL, TokenPlaceholder); i >= 0 {
+		escaped := url.QueryEscape(token)
+		if !strings.ContainsAny(baseURL[:i], "?#") {
+			escaped = url.PathEscape(token)
+		}
+
+		baseURL = strings.ReplaceAll(baseURL, TokenPlaceholder, escaped)
+
+		if _, err := url.Parse(baseURL); err != nil {
+			return "", err
+		}
+
+		return baseURL, nil
+	}
+
 	base, err := url.Parse(baseURL)
 	if err != nil {
 		return "", err
 	}
 
-	url := base.ResolveReference(&url.URL{RawQuery: url.Values{"token": []string{token}}.Encode()})
+	query := base.Query()
+	query.Set(tokenParam, token)
+	base.RawQuery = query.Encode()
 
-	return url.String(), nil
+	return base.String(), nil
 }
 
 // NewVerifyBillingEmail returns a new email message based on the config values and the provided recipient and token
-func (c Config) NewVerifyBillingEmail(r Recipient, token string) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewVerifyBillingEmail(r Recipient, token string, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("verify_billing"); err != nil {
 		return nil, err
 	}
 
@@ -192,9 +270,11 @@ func (c Config) NewVerifyBillingEmail(r Recipient, token string) (*newman.EmailM
 		},
 	}
 
+	data.Apply(opts...)
+
 	var err error
 
-	data.URLS.VerifyBilling, err = addTokenToURL(c.URLS.VerifyBilling, token)
+	data.URLS.VerifyBilling, err = c.actionURL(&data.EmailData, c.URLS.VerifyBilling, PurposeVerifyBilling, token)
 	if err != nil {
 		return nil, err
 	}
@@ -223,8 +303,8 @@ type TrustCenterNDASignedData struct {
 // NewTrustCenterNDASignedEmail creates a new email message notifying the recipient that their NDA has been signed
 // and they now have access to the organization's trust center resources.
 // The attachment parameter is the signed NDA document to include as an email attachment.
-func (c Config) NewTrustCenterNDASignedEmail(r Recipient, data TrustCenterNDASignedData, attachment io.Reader, fileName string) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewTrustCenterNDASignedEmail(r Recipient, data TrustCenterNDASignedData, attachment io.Reader, fileName string, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("trust_center_nda_signed"); err != nil {
 		return nil, err
 	}
 
@@ -246,14 +326,10 @@ func (c Config) NewTrustCenterNDASignedEmail(r Recipient, data TrustCenterNDASig
 		TrustCenterURL:   data.TrustCenterURL,
 	}
 
-	msg, err := trustCenterNDASigned(emailData)
-	if err != nil {
-		return nil, err
-	}
-
-	msg.AddAttachment(newman.NewAttachment(fileName, content))
+	// the signed NDA is attached before the options so it is the first attachment
+	emailData.Apply(append([]EmailOption{WithAttachment(fileName, content)}, opts...)...)
 
-	return msg, nil
+	return trustCenterNDASigned(emailData)
 }
 
 // TrustCenterAuthData contains the data needed to create a trust center auth link email
@@ -279,8 +355,8 @@ type QuestionnaireAuthData struct {
 // NewTrustCenterNDARequestEmail creates a new email message for requesting an NDA signature to access the trust center.
 // It takes a recipient, a security token, and trust center NDA request data, then generates an email
 // with a tokenized URL that allows the recipient to sign the NDA and gain access to protected trust center resources.
-func (c Config) NewTrustCenterNDARequestEmail(r Recipient, token string, data TrustCenterNDARequestData) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewTrustCenterNDARequestEmail(r Recipient, token string, data TrustCenterNDARequestData, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("trust_center_nda_request"); err != nil {
 		return nil, err
 	}
 
@@ -294,9 +370,13 @@ func (c Config) NewTrustCenterNDARequestEmail(r Recipient, token string, data Tr
 		OrganizationName: data.OrganizationName,
 	}
 
+	emailData.Apply(opts...)
+
 	emailData.TrustCenterNDAURL = data.TrustCenterNDAFullURL
+	emailData.addActionLinks(data.TrustCenterNDAFullURL)
+
 	if emailData.TrustCenterNDAURL == "" {
-		emailData.TrustCenterNDAURL, err = addTokenToURL(data.TrustCenterURL, token)
+		emailData.TrustCenterNDAURL, err = c.actionURL(&emailData.EmailData, data.TrustCenterURL, PurposeTrustCenterNDA, token)
 		if err != nil {
 			return nil, err
 		}
@@ -308,8 +388,8 @@ func (c Config) NewTrustCenterNDARequestEmail(r Recipient, token string, data Tr
 // NewTrustCenterAuthEmail creates a new email message with an authentication link to access the trust center.
 // It takes a recipient, a security token, and trust center auth data, then generates an email
 // with a tokenized URL that allows the recipient to authenticate and access trust center resources directly.
-func (c Config) NewTrustCenterAuthEmail(r Recipient, token string, data TrustCenterAuthData) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewTrustCenterAuthEmail(r Recipient, token string, data TrustCenterAuthData, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("trust_center_auth"); err != nil {
 		return nil, err
 	}
 
@@ -323,9 +403,13 @@ func (c Config) NewTrustCenterAuthEmail(r Recipient, token string, data TrustCen
 		OrganizationName: data.OrganizationName,
 	}
 
+	emailData.Apply(opts...)
+
 	emailData.TrustCenterAuthURL = data.TrustCenterAuthFullURL
+	emailData.addActionLinks(data.TrustCenterAuthFullURL)
+
 	if emailData.TrustCenterAuthURL == "" {
-		emailData.TrustCenterAuthURL, err = addTokenToURL(data.TrustCenterURL, token)
+		emailData.TrustCenterAuthURL, err = c.actionURL(&emailData.EmailData, data.TrustCenterURL, PurposeTrustCenterAuth, token)
 		if err != nil {
 			return nil, err
 		}
@@ -337,8 +421,8 @@ func (c Config) NewTrustCenterAuthEmail(r Recipient, token string, data TrustCen
 // NewQuestionnaireAuthEmail creates a new email message with an authentication link to access a questionnaire.
 // It takes a recipient, a security token, and questionnaire auth data, then generates an email
 // with a tokenized URL that allows the recipient to authenticate and access the questionnaire directly.
-func (c Config) NewQuestionnaireAuthEmail(r Recipient, token string, data QuestionnaireAuthData) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewQuestionnaireAuthEmail(r Recipient, token string, data QuestionnaireAuthData, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("questionnaire_auth"); err != nil {
 		return nil, err
 	}
 
@@ -351,15 +435,19 @@ func (c Config) NewQuestionnaireAuthEmail(r Recipient, token string, data Questi
 		AssessmentName: data.AssessmentName,
 	}
 
-	if c.QuestionnaireEmail != "" {
-		emailData.FromEmail = c.QuestionnaireEmail
-	}
+	emailData.Apply(opts...)
 
 	emailData.QuestionnaireAuthURL = data.QuestionnaireAuthFullURL
+	emailData.addActionLinks(data.QuestionnaireAuthFullURL)
+
 	if emailData.QuestionnaireAuthURL == "" {
+		if err := c.requireURLs("URLS.Questionnaire"); err != nil {
+			return nil, err
+		}
+
 		var err error
 
-		emailData.QuestionnaireAuthURL, err = addTokenToURL(c.URLS.Questionnaire, token)
+		emailData.QuestionnaireAuthURL, err = c.actionURL(&emailData.EmailData, c.URLS.Questionnaire, PurposeQuestionnaire, token)
 		if err != nil {
 			return nil, err
 		}
@@ -378,8 +466,8 @@ type BillingEmailChangedTemplateData struct {
 
 // NewBillingEmailChangedEmail creates a new email message that is meant to notify orgs
 // about changes to their billing email.
-func (c Config) NewBillingEmailChangedEmail(r Recipient, data BillingEmailChangedTemplateData) (*newman.EmailMessage, error) {
-	if err := c.ensureDefaults(); err != nil {
+func (c Config) NewBillingEmailChangedEmail(r Recipient, data BillingEmailChangedTemplateData, opts ...EmailOption) (*newman.EmailMessage, error) {
+	if err := c.ensureDefaults("billing_email_changed"); err != nil {
 		return nil, err
 	}
 
@@ -394,5 +482,7 @@ func (c Config) NewBillingEmailChangedEmail(r Recipient, data BillingEmailChange
 		ChangedAt:        data.ChangedAt,
 	}
 
+	emailData.Apply(opts...)
+
 	return billingEmailChanged(emailData)
 }
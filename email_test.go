@@ -21,6 +21,48 @@ func TestAddTokenToURL(t *testing.T) {
 			expectedURL:   "https://example.com/verify?token=validtoken",
 			expectedError: false,
 		},
+		{
+			name:          "existing query parameters are kept",
+			baseURL:       "https://example.com/verify?org=meow",
+			token:         "validtoken",
+			expectedURL:   "https://example.com/verify?org=meow&token=validtoken",
+			expectedError: false,
+		},
+		{
+			name:          "existing token is replaced",
+			baseURL:       "https://example.com/verify?token=old",
+			token:         "validtoken",
+			expectedURL:   "https://example.com/verify?token=validtoken",
+			expectedError: false,
+		},
+		{
+			name:          "token in path segment",
+			baseURL:       "https://example.com/verify/{token}/confirm",
+			token:         "valid/token",
+			expectedURL:   "https://example.com/verify/valid%2Ftoken/confirm",
+			expectedError: false,
+		},
+		{
+			name:          "token in fragment",
+			baseURL:       "https://example.com/verify?org=meow#token={token}",
+			token:         "valid token",
+			expectedURL:   "https://example.com/verify?org=meow#token=valid+token",
+			expectedError: false,
+		},
+		{
+			name:          "token under a custom parameter",
+			baseURL:       "https://example.com/verify?org=meow&t={token}",
+			token:         "validtoken",
+			expectedURL:   "https://example.com/verify?org=meow&t=validtoken",
+			expectedError: false,
+		},
+		{
+			name:          "placeholder with invalid base URL",
+			baseURL:       "://invalid-url/{token}",
+			token:         "validtoken",
+			expectedURL:   "",
+			expectedError: true,
+		},
 		{
 			name:          "empty token",
 			baseURL:       "https://example.com/verify",
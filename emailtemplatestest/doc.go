@@ -0,0 +1,3 @@
+// Package emailtemplatestest provides helpers for testing emails built with the emailtemplates
+// package, including golden file comparisons for teams that override the default templates
+package emailtemplatestest
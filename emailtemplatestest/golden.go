@@ -0,0 +1,230 @@
+package emailtemplatestest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/theopenlane/newman"
+
+	"github.com/theopenlane/emailtemplates"
+)
+
+const (
+	// updateFlag is the name of the test flag used to regenerate golden files, e.g. go test ./... -update
+	updateFlag = "update"
+
+	// goldenExt is appended to every golden file
+	goldenExt = ".golden"
+
+	// diffContext is the number of unchanged lines shown around each change in a diff
+	diffContext = 3
+
+	// goldenDirPerm and goldenFilePerm are the permissions used when writing golden files
+	goldenDirPerm  = 0o755
+	goldenFilePerm = 0o600
+)
+
+// the flag is only registered when the consumer has not already defined one with the same name
+func init() {
+	if flag.Lookup(updateFlag) == nil {
+		flag.Bool(updateFlag, false, "update the email golden files instead of comparing against them")
+	}
+}
+
+// copyrightYear matches the copyright year rendered in the footers, which changes every year
+var copyrightYear = regexp.MustCompile(`(©|&copy;)\s*\d{4}`)
+
+// Normalizer rewrites rendered content before it is compared or written to a golden file
+type Normalizer func(string) string
+
+// GoldenOption configures a golden file comparison
+type GoldenOption func(*golden)
+
+// golden holds the configuration of a single golden file comparison
+type golden struct {
+	set         *emailtemplates.TemplateSet
+	normalizers []Normalizer
+	update      bool
+}
+
+// WithTemplateSet renders templates from the provided set instead of the package level templates,
+// e.g. a set loaded with emailtemplates.LoadTemplateSet from a custom templates directory
+func WithTemplateSet(set *emailtemplates.TemplateSet) GoldenOption {
+	return func(g *golden) {
+		g.set = set
+	}
+}
+
+// WithNormalizer adds a normalizer that is applied after the defaults
+func WithNormalizer(n Normalizer) GoldenOption {
+	return func(g *golden) {
+		g.normalizers = append(g.normalizers, n)
+	}
+}
+
+// WithReplacement replaces every occurrence of a volatile value, e.g. a generated token, with a placeholder
+func WithReplacement(value, placeholder string) GoldenOption {
+	return WithNormalizer(func(s string) string {
+		return strings.ReplaceAll(s, value, placeholder)
+	})
+}
+
+// WithRegexpReplacement replaces every match of the expression with a placeholder, which may
+// reference submatches the same way as regexp.ReplaceAllString
+func WithRegexpReplacement(re *regexp.Regexp, placeholder string) GoldenOption {
+	return WithNormalizer(func(s string) string {
+		return re.ReplaceAllString(s, placeholder)
+	})
+}
+
+// WithUpdate forces the golden files to be (re)written regardless of the -update flag
+func WithUpdate(update bool) GoldenOption {
+	return func(g *golden) {
+		g.update = update
+	}
+}
+
+// newGolden returns the golden configuration with the defaults applied
+func newGolden(opts []GoldenOption) *golden {
+	g := &golden{}
+
+	if f := flag.Lookup(updateFlag); f != nil {
+		g.update, _ = strconv.ParseBool(f.Value.String())
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// AssertTemplate renders the named template with data and compares the subject, text and html
+// against the golden files in dir, named <name>.subject.golden, <name>.txt.golden and <name>.html.golden.
+// The subject is only compared for the built in emails. Run the tests with -update to regenerate the files
+func AssertTemplate(t testing.TB, dir, name string, data interface{}, opts ...GoldenOption) {
+	t.Helper()
+
+	g := newGolden(opts)
+
+	var (
+		text, html string
+		err        error
+	)
+
+	if g.set != nil {
+		text, html, err = g.set.Render(name, data)
+	} else {
+		text, html, err = emailtemplates.Render(name, data)
+	}
+
+	if err != nil {
+		t.Fatalf("could not render template %q: %v", name, err)
+	}
+
+	if subject := emailtemplates.Subject(name, data); subject != "" {
+		g.assert(t, filepath.Join(dir, name+".subject"+goldenExt), subject)
+	}
+
+	g.assert(t, filepath.Join(dir, name+".txt"+goldenExt), text)
+	g.assert(t, filepath.Join(dir, name+".html"+goldenExt), html)
+}
+
+// AssertMessage compares the subject, text and html of a built message against the golden files
+// in dir, named the same way as AssertTemplate. Run the tests with -update to regenerate the files
+func AssertMessage(t testing.TB, dir, name string, msg *newman.EmailMessage, opts ...GoldenOption) {
+	t.Helper()
+
+	if msg == nil {
+		t.Fatalf("message %q is nil", name)
+	}
+
+	g := newGolden(opts)
+
+	g.assert(t, filepath.Join(dir, name+".subject"+goldenExt), msg.Subject)
+	g.assert(t, filepath.Join(dir, name+".txt"+goldenExt), msg.Text)
+	g.assert(t, filepath.Join(dir, name+".html"+goldenExt), msg.HTML)
+}
+
+// assert compares the normalized content against the golden file, or writes it when updating
+func (g *golden) assert(t testing.TB, file, got string) {
+	t.Helper()
+
+	got = g.normalize(got)
+
+	if g.update {
+		if err := os.MkdirAll(filepath.Dir(file), goldenDirPerm); err != nil {
+			t.Fatalf("could not create golden directory: %v", err)
+		}
+
+		if err := os.WriteFile(file, []byte(got), goldenFilePerm); err != nil {
+			t.Fatalf("could not update golden file: %v", err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("could not read golden file, run the tests with -%s to create it: %v", updateFlag, err)
+	}
+
+	if expected := g.normalize(string(want)); expected != got {
+		t.Errorf("%s does not match, run the tests with -%s to accept the changes:\n%s", file, updateFlag, Diff(expected, got))
+	}
+}
+
+// normalize applies the default normalizers followed by any configured ones
+func (g *golden) normalize(s string) string {
+	s = NormalizeWhitespace(s)
+	s = copyrightYear.ReplaceAllString(s, "$1 YEAR")
+
+	for _, n := range g.normalizers {
+		s = n(s)
+	}
+
+	return s
+}
+
+// NormalizeWhitespace normalizes line endings, strips trailing whitespace from every line,
+// collapses runs of blank lines into a single blank line and trims the content
+func NormalizeWhitespace(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+
+		if line == "" && len(out) > 0 && out[len(out)-1] == "" {
+			continue
+		}
+
+		out = append(out, line)
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n")) + "\n"
+}
+
+// Diff returns a unified diff between the expected and actual content
+func Diff(expected, actual string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(expected),
+		B:        difflib.SplitLines(actual),
+		FromFile: "expected",
+		ToFile:   "actual",
+		Context:  diffContext,
+	})
+	if err != nil {
+		return err.Error()
+	}
+
+	return diff
+}
@@ -0,0 +1,98 @@
+package emailtemplatestest
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theopenlane/newman"
+
+	"github.com/theopenlane/emailtemplates"
+)
+
+// recorder captures failures instead of failing the test
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertTemplateBuiltins(t *testing.T) {
+	set, err := emailtemplates.LoadTemplateSet("")
+	require.NoError(t, err)
+
+	for _, name := range set.Names() {
+		t.Run(name, func(t *testing.T) {
+			data, err := emailtemplates.SampleData(name, emailtemplates.DefaultSample)
+			require.NoError(t, err)
+
+			AssertTemplate(t, "testdata/golden", name, data, WithTemplateSet(set))
+		})
+	}
+}
+
+func TestAssertTemplateMismatch(t *testing.T) {
+	dir := t.TempDir()
+
+	data, err := emailtemplates.SampleData("welcome", emailtemplates.DefaultSample)
+	require.NoError(t, err)
+
+	// missing golden files fail with a hint to update
+	r := &recorder{TB: t}
+	AssertTemplate(r, dir, "welcome", data)
+	require.NotEmpty(t, r.failures)
+	assert.Contains(t, r.failures[0], "-update")
+
+	AssertTemplate(t, dir, "welcome", data, WithUpdate(true))
+	assert.FileExists(t, filepath.Join(dir, "welcome.subject.golden"))
+	assert.FileExists(t, filepath.Join(dir, "welcome.txt.golden"))
+	assert.FileExists(t, filepath.Join(dir, "welcome.html.golden"))
+
+	// the same data matches the files that were just written
+	AssertTemplate(t, dir, "welcome", data)
+
+	welcome, ok := data.(emailtemplates.WelcomeData)
+	require.True(t, ok)
+
+	welcome.CompanyName = "Avengers"
+
+	r = &recorder{TB: t}
+	AssertTemplate(r, dir, "welcome", welcome)
+	require.NotEmpty(t, r.failures)
+	assert.Contains(t, r.failures[0], "-Welcome to Openlane!")
+	assert.Contains(t, r.failures[0], "+Welcome to Avengers!")
+
+	// volatile values can be normalized away
+	AssertTemplate(t, dir, "welcome", welcome, WithReplacement("Avengers", "Openlane"))
+}
+
+func TestAssertMessage(t *testing.T) {
+	dir := t.TempDir()
+
+	msg := newman.NewEmailMessageWithOptions(
+		newman.WithSubject("Hello"),
+		newman.WithText("Hello world  \r\n\r\n\r\n© 2024 Openlane"),
+		newman.WithHTML("<p>Hello</p>"),
+	)
+
+	AssertMessage(t, dir, "hello", msg, WithUpdate(true))
+
+	// whitespace and the copyright year are normalized
+	msg.Text = "Hello world\n\n© 2031 Openlane\n"
+	AssertMessage(t, dir, "hello", msg)
+}
+
+func TestNormalizeWhitespace(t *testing.T) {
+	assert.Equal(t, "a\n\nb\n", NormalizeWhitespace("\n  a  \r\n\r\n\n\nb\t\n\n"))
+}
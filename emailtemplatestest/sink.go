@@ -0,0 +1,333 @@
+package emailtemplatestest
+
+import (
+	"context"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/theopenlane/newman"
+
+	"github.com/theopenlane/emailtemplates"
+)
+
+var (
+	// hrefPattern matches the href attribute of links in html bodies
+	hrefPattern = regexp.MustCompile(`href\s*=\s*["']([^"']+)["']`)
+	// urlPattern matches absolute urls in text bodies
+	urlPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+)
+
+// Sink is an in memory newman.EmailSender that captures every message sent through it instead
+// of delivering it, so application tests can assert on the emails that were sent.
+// It is safe for concurrent use
+type Sink struct {
+	mu       sync.RWMutex
+	messages []*newman.EmailMessage
+	err      error
+}
+
+// NewSink returns an empty sink
+func NewSink() *Sink {
+	return &Sink{}
+}
+
+// SendEmail captures the message
+func (s *Sink) SendEmail(message *newman.EmailMessage) error {
+	return s.SendEmailWithContext(context.Background(), message)
+}
+
+// SendEmailWithContext captures the message, unless the context is done or the sink was
+// configured to fail with FailWith
+func (s *Sink) SendEmailWithContext(ctx context.Context, message *newman.EmailMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	s.messages = append(s.messages, message)
+
+	return nil
+}
+
+// FailWith makes every following send return err, pass nil to resume capturing messages
+func (s *Sink) FailWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+// Reset removes all captured messages
+func (s *Sink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+}
+
+// Messages returns all captured messages in the order they were sent
+func (s *Sink) Messages() []*newman.EmailMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]*newman.EmailMessage{}, s.messages...)
+}
+
+// Find returns the captured messages matching all filters, in the order they were sent
+func (s *Sink) Find(filters ...Filter) []*newman.EmailMessage {
+	out := []*newman.EmailMessage{}
+
+	for _, msg := range s.Messages() {
+		if matches(msg, filters) {
+			out = append(out, msg)
+		}
+	}
+
+	return out
+}
+
+// Last returns the most recent message matching all filters, or nil if there is none
+func (s *Sink) Last(filters ...Filter) *newman.EmailMessage {
+	found := s.Find(filters...)
+	if len(found) == 0 {
+		return nil
+	}
+
+	return found[len(found)-1]
+}
+
+// AssertSent stops the test if no captured message matches all filters, and returns the most recent match
+// so it can be used without a nil check
+func (s *Sink) AssertSent(t testing.TB, filters ...Filter) *newman.EmailMessage {
+	t.Helper()
+
+	msg := s.Last(filters...)
+	if msg == nil {
+		t.Fatalf("expected a matching email to be sent, captured %d emails: %s", len(s.Messages()), s.summary())
+	}
+
+	return msg
+}
+
+// AssertNotSent fails the test if any captured message matches all filters
+func (s *Sink) AssertNotSent(t testing.TB, filters ...Filter) {
+	t.Helper()
+
+	if found := s.Find(filters...); len(found) > 0 {
+		t.Errorf("expected no matching email to be sent, found %d", len(found))
+	}
+}
+
+// AssertCount fails the test if the number of captured messages matching all filters is not n
+func (s *Sink) AssertCount(t testing.TB, n int, filters ...Filter) {
+	t.Helper()
+
+	if found := s.Find(filters...); len(found) != n {
+		t.Errorf("expected %d matching emails to be sent, found %d", n, len(found))
+	}
+}
+
+// summary describes the captured messages for failure output
+func (s *Sink) summary() string {
+	lines := []string{}
+
+	for _, msg := range s.Messages() {
+		lines = append(lines, "\n\t"+strings.Join(msg.To, ", ")+": "+msg.Subject)
+	}
+
+	return strings.Join(lines, "")
+}
+
+// Filter matches captured messages
+type Filter func(*newman.EmailMessage) bool
+
+// matches reports whether the message matches every filter
+func matches(msg *newman.EmailMessage, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(msg) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// To matches messages addressed to the email in the to, cc or bcc recipients
+func To(email string) Filter {
+	return func(msg *newman.EmailMessage) bool {
+		for _, recipients := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+			for _, r := range recipients {
+				if strings.EqualFold(addressOf(r), email) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+}
+
+// Subject matches messages with the exact subject
+func Subject(subject string) Filter {
+	return func(msg *newman.EmailMessage) bool {
+		return msg.Subject == subject
+	}
+}
+
+// SubjectContains matches messages with a subject containing substr
+func SubjectContains(substr string) Filter {
+	return func(msg *newman.EmailMessage) bool {
+		return strings.Contains(msg.Subject, substr)
+	}
+}
+
+// Template matches messages rendered from the named template, e.g. password_reset_request
+func Template(name string) Filter {
+	return func(msg *newman.EmailMessage) bool {
+		return TemplateOf(msg) == name
+	}
+}
+
+// BodyContains matches messages with a text or html body containing substr
+func BodyContains(substr string) Filter {
+	return func(msg *newman.EmailMessage) bool {
+		return strings.Contains(msg.Text, substr) || strings.Contains(msg.HTML, substr)
+	}
+}
+
+// WithLinkToken matches messages containing a link that carries the token
+func WithLinkToken(token string) Filter {
+	return func(msg *newman.EmailMessage) bool {
+		for _, t := range Tokens(msg) {
+			if t == token {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// WithAttachment matches messages with an attachment with the file name
+func WithAttachment(filename string) Filter {
+	return func(msg *newman.EmailMessage) bool {
+		return attachment(msg, filename) != nil
+	}
+}
+
+// TemplateOf returns the name of the template the message was rendered from
+func TemplateOf(msg *newman.EmailMessage) string {
+	for _, tag := range msg.Tags {
+		if tag.Name == emailtemplates.TemplateTag {
+			return tag.Value
+		}
+	}
+
+	return ""
+}
+
+// Links returns the unique links found in the text and html bodies of the message, in the order they appear
+func Links(msg *newman.EmailMessage) []string {
+	seen := map[string]bool{}
+	out := []string{}
+
+	add := func(link string) {
+		link = html.UnescapeString(strings.TrimSpace(link))
+		if link == "" || seen[link] {
+			return
+		}
+
+		seen[link] = true
+		out = append(out, link)
+	}
+
+	for _, match := range hrefPattern.FindAllStringSubmatch(msg.HTML, -1) {
+		add(match[1])
+	}
+
+	for _, match := range urlPattern.FindAllString(msg.Text, -1) {
+		add(match)
+	}
+
+	return out
+}
+
+// Tokens returns the unique tokens carried by the links in the message, found in the query or fragment
+// parameters with the provided names, which defaults to token
+func Tokens(msg *newman.EmailMessage, params ...string) []string {
+	if len(params) == 0 {
+		params = []string{"token"}
+	}
+
+	seen := map[string]bool{}
+	out := []string{}
+
+	for _, link := range Links(msg) {
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+
+		fragment, _ := url.ParseQuery(u.Fragment)
+
+		for _, values := range []url.Values{u.Query(), fragment} {
+			for _, param := range params {
+				if v := values.Get(param); v != "" && !seen[v] {
+					seen[v] = true
+					out = append(out, v)
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// AssertAttachment stops the test if the message is nil or has no attachment with the file name, and
+// returns it so it can be used without a nil check
+func AssertAttachment(t testing.TB, msg *newman.EmailMessage, filename string) *newman.Attachment {
+	t.Helper()
+
+	if msg == nil {
+		t.Fatalf("expected attachment %q on an email, got no email", filename)
+
+		return nil
+	}
+
+	a := attachment(msg, filename)
+	if a == nil {
+		t.Fatalf("expected attachment %q on email %q", filename, msg.Subject)
+	}
+
+	return a
+}
+
+// attachment returns the attachment with the file name, or nil if there is none
+func attachment(msg *newman.EmailMessage, filename string) *newman.Attachment {
+	for _, a := range msg.Attachments {
+		if a != nil && a.Filename == filename {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// addressOf returns the bare address of a recipient which may include a display name
+func addressOf(recipient string) string {
+	if start, end := strings.LastIndex(recipient, "<"), strings.LastIndex(recipient, ">"); start >= 0 && end > start {
+		return recipient[start+1 : end]
+	}
+
+	return strings.TrimSpace(recipient)
+}
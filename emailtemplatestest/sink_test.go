@@ -0,0 +1,114 @@
+package emailtemplatestest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/theopenlane/emailtemplates"
+)
+
+func newTestConfig(t *testing.T) *emailtemplates.Config {
+	t.Helper()
+
+	cfg, err := emailtemplates.New(
+		emailtemplates.WithCompanyName("Openlane"),
+		emailtemplates.WithCompanyAddress("5150 Broadway St"),
+		emailtemplates.WithFromEmail("no-reply@example.com"),
+		emailtemplates.WithResetURL("https://console.example.com/password-reset"),
+	)
+	require.NoError(t, err)
+
+	return cfg
+}
+
+func TestSink(t *testing.T) {
+	cfg := newTestConfig(t)
+	sink := NewSink()
+
+	msg, err := cfg.NewPasswordResetRequestEmail(emailtemplates.Recipient{Email: "x@example.com"}, "token-y")
+	require.NoError(t, err)
+	require.NoError(t, sink.SendEmail(msg))
+
+	msg, err = cfg.NewTrustCenterNDASignedEmail(emailtemplates.Recipient{Email: "legal@example.com"},
+		emailtemplates.TrustCenterNDASignedData{OrganizationName: "Meow", TrustCenterURL: "https://trust.example.com"},
+		strings.NewReader("signed"), "nda.pdf")
+	require.NoError(t, err)
+	require.NoError(t, sink.SendEmailWithContext(context.Background(), msg))
+
+	reset := sink.AssertSent(t, To("X@example.com"), Template("password_reset_request"), WithLinkToken("token-y"))
+	require.NotNil(t, reset)
+	assert.Contains(t, Links(reset), "https://console.example.com/password-reset?token=token-y")
+	assert.Equal(t, []string{"token-y"}, Tokens(reset))
+
+	nda := sink.AssertSent(t, To("legal@example.com"), WithAttachment("nda.pdf"))
+	require.NotNil(t, nda)
+	AssertAttachment(t, nda, "nda.pdf")
+
+	sink.AssertNotSent(t, To("x@example.com"), Template("welcome"))
+	sink.AssertCount(t, 2)
+	sink.AssertCount(t, 1, SubjectContains("Password Reset"))
+	assert.Nil(t, sink.Last(Subject("nope")))
+
+	sink.Reset()
+	assert.Empty(t, sink.Messages())
+}
+
+func TestSinkFailures(t *testing.T) {
+	sink := NewSink()
+	errProvider := errors.New("provider down")
+
+	sink.FailWith(errProvider)
+	require.ErrorIs(t, sink.SendEmail(nil), errProvider)
+
+	sink.FailWith(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.ErrorIs(t, sink.SendEmailWithContext(ctx, nil), context.Canceled)
+	assert.Empty(t, sink.Messages())
+
+	// assertions report failures through the testing.TB
+	r := &recorder{TB: t}
+	assert.Nil(t, sink.AssertSent(r, To("nobody@example.com")))
+	assert.Len(t, r.failures, 1)
+
+	// a missing email is reported rather than dereferenced
+	assert.Nil(t, AssertAttachment(r, nil, "nda.pdf"))
+	assert.Len(t, r.failures, 2)
+}
+
+func TestSinkParallel(t *testing.T) {
+	cfg := newTestConfig(t)
+	sink := NewSink()
+
+	const count = 20
+
+	var wg sync.WaitGroup
+
+	for i := range count {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			msg, err := cfg.NewPasswordResetRequestEmail(emailtemplates.Recipient{Email: fmt.Sprintf("user%d@example.com", i)}, fmt.Sprintf("token-%d", i))
+			assert.NoError(t, err)
+			assert.NoError(t, sink.SendEmail(msg))
+
+			sink.Find(To("user0@example.com"))
+		}()
+	}
+
+	wg.Wait()
+
+	sink.AssertCount(t, count, Template("password_reset_request"))
+	sink.AssertSent(t, To("user7@example.com"), WithLinkToken("token-7"))
+}
@@ -3,11 +3,42 @@ package emailtemplates
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
 	// ErrMissingTemplate is returned when an email template is missing from the template directory
 	ErrMissingTemplate = errors.New("missing email template")
+	// ErrInvalidLink is returned when a rendered email contains links that fail validation
+	ErrInvalidLink = errors.New("invalid link")
+	// ErrSigningKeyNotFound is returned when the key used to sign or verify a URL is not configured
+	ErrSigningKeyNotFound = errors.New("signing key not found")
+	// ErrInvalidSignature is returned when a signed URL has a missing or invalid signature
+	ErrInvalidSignature = errors.New("invalid url signature")
+	// ErrSignedURLExpired is returned when a signed URL is past its expiry
+	ErrSignedURLExpired = errors.New("signed url has expired")
+	// ErrSignedURLPurpose is returned when a signed URL is used for a different purpose than it was signed for
+	ErrSignedURLPurpose = errors.New("signed url is not valid for this purpose")
+	// ErrInvalidAddress is returned when a cc, bcc or reply to address cannot be parsed
+	ErrInvalidAddress = errors.New("invalid email address")
+	// ErrRecipientSuppressed is returned when an email is built for a recipient on the suppression list
+	ErrRecipientSuppressed = errors.New("recipient is suppressed")
+	// ErrSandboxRecipient is returned when the sandbox has no redirect address and the recipient is outside the allowed domains
+	ErrSandboxRecipient = errors.New("recipient is not allowed in the sandbox")
+	// ErrInvalidConfig is returned when the config has invalid fields, see ValidationErrors
+	ErrInvalidConfig = errors.New("invalid config")
+	// ErrInvalidConfigValue is returned when a loaded config value cannot be converted to the type of its field
+	ErrInvalidConfigValue = errors.New("invalid config value")
+	// ErrUnsupportedConfigFormat is returned when a config file is not YAML or JSON
+	ErrUnsupportedConfigFormat = errors.New("unsupported config file format")
+	// ErrInvalidURL is returned when a URL of the config is not an absolute https URL
+	ErrInvalidURL = errors.New("must be an absolute https url")
+	// ErrInvalidTrackedURL is returned when the destination of a tracked link is not an absolute http or https URL
+	ErrInvalidTrackedURL = errors.New("tracked url must be an absolute http or https url")
+	// ErrSampleNotFound is returned when a template has no sample with the requested name
+	ErrSampleNotFound = errors.New("sample not found")
+	// ErrInvalidLogoURL is returned when the logo URL is not an https URL to an image
+	ErrInvalidLogoURL = errors.New("must be an https url to a png, jpg, gif, svg or webp image")
 )
 
 // MissingRequiredFieldError is returned when a required field was not provided in a request
@@ -27,3 +58,108 @@ func newMissingRequiredFieldError(field string) *MissingRequiredFieldError {
 		RequiredField: field,
 	}
 }
+
+// LinkProblem describes a single link that failed validation
+type LinkProblem struct {
+	// URL is the link as it appears in the email
+	URL string
+	// Reason the link is invalid
+	Reason string
+}
+
+// LinkValidationError is returned when one or more links in a rendered email fail validation
+type LinkValidationError struct {
+	// Problems with every invalid link, in the order they appear
+	Problems []LinkProblem
+}
+
+// Error returns the LinkValidationError in string format
+func (e *LinkValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+
+	for _, p := range e.Problems {
+		problems = append(problems, fmt.Sprintf("%q %s", p.URL, p.Reason))
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidLink, strings.Join(problems, "; "))
+}
+
+// Unwrap returns ErrInvalidLink so the error can be checked with errors.Is
+func (e *LinkValidationError) Unwrap() error {
+	return ErrInvalidLink
+}
+
+// SuppressedError is returned when an email is built for a suppressed recipient
+type SuppressedError struct {
+	// Email is the suppressed recipient
+	Email string
+	// Reason the recipient is suppressed
+	Reason SuppressionReason
+}
+
+// Error returns the SuppressedError in string format
+func (e *SuppressedError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", ErrRecipientSuppressed, e.Email, e.Reason)
+}
+
+// Unwrap returns ErrRecipientSuppressed so the error can be checked with errors.Is
+func (e *SuppressedError) Unwrap() error {
+	return ErrRecipientSuppressed
+}
+
+// FieldError is a problem with a single field of the config
+type FieldError struct {
+	// Field is the path of the field, e.g. URLS.Verify
+	Field string
+	// Err is the problem with the field
+	Err error
+}
+
+// Error returns the FieldError in string format
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Err)
+}
+
+// Unwrap returns the problem with the field so the error can be checked with errors.Is and errors.As
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors is returned when the config has invalid fields, listing every problem at once
+type ValidationErrors struct {
+	// Errors with every invalid field, in the order the fields are declared
+	Errors []*FieldError
+}
+
+// Error returns the ValidationErrors in string format
+func (e *ValidationErrors) Error() string {
+	problems := make([]string, 0, len(e.Errors))
+
+	for _, fe := range e.Errors {
+		problems = append(problems, fe.Error())
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+}
+
+// Unwrap returns ErrInvalidConfig and the field errors so the error can be checked with errors.Is and errors.As
+func (e *ValidationErrors) Unwrap() []error {
+	errs := []error{ErrInvalidConfig}
+
+	for _, fe := range e.Errors {
+		errs = append(errs, fe)
+	}
+
+	return errs
+}
+
+// Fields returns the paths of the invalid fields
+func (e *ValidationErrors) Fields() []string {
+	fields := make([]string, 0, len(e.Errors))
+
+	for _, fe := range e.Errors {
+		fields = append(fields, fe.Field)
+	}
+
+	return fields
+}
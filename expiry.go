@@ -0,0 +1,135 @@
+package emailtemplates
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLocale is used to humanize durations when no locale, or an unsupported one, is configured
+	DefaultLocale = "en"
+
+	// maxDurationUnits is the number of units a humanized duration is shown in
+	maxDurationUnits = 2
+)
+
+// durationUnit is a unit a duration is humanized in
+type durationUnit struct {
+	size             time.Duration
+	singular, plural string
+}
+
+// durationLocale holds the words used to humanize durations and the layout of timestamps in a language
+type durationLocale struct {
+	day, hour, minute [2]string
+	and               string
+	lessThanAMinute   string
+	timestamp         string
+}
+
+// durationLocales are the supported languages, keyed by the base language of the locale
+var durationLocales = map[string]durationLocale{
+	"en": {
+		day: [2]string{"day", "days"}, hour: [2]string{"hour", "hours"}, minute: [2]string{"minute", "minutes"},
+		and: "and", lessThanAMinute: "less than a minute", timestamp: "January 2, 2006 at 15:04 MST",
+	},
+	"es": {
+		day: [2]string{"día", "días"}, hour: [2]string{"hora", "horas"}, minute: [2]string{"minuto", "minutos"},
+		and: "y", lessThanAMinute: "menos de un minuto", timestamp: "02/01/2006 15:04 MST",
+	},
+	"fr": {
+		day: [2]string{"jour", "jours"}, hour: [2]string{"heure", "heures"}, minute: [2]string{"minute", "minutes"},
+		and: "et", lessThanAMinute: "moins d'une minute", timestamp: "02/01/2006 15:04 MST",
+	},
+	"de": {
+		day: [2]string{"Tag", "Tage"}, hour: [2]string{"Stunde", "Stunden"}, minute: [2]string{"Minute", "Minuten"},
+		and: "und", lessThanAMinute: "weniger als eine Minute", timestamp: "02.01.2006 15:04 MST",
+	},
+}
+
+// localeFor returns the words for the locale, e.g. es-MX uses es, falling back to the default locale
+func localeFor(locale string) durationLocale {
+	base, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+
+	if l, ok := durationLocales[strings.ToLower(base)]; ok {
+		return l
+	}
+
+	return durationLocales[DefaultLocale]
+}
+
+// HumanizeDuration returns the duration in words in the locale using at most the two largest units,
+// e.g. 1 hour and 30 minutes; it is available in templates as HumanizeDuration
+func HumanizeDuration(d time.Duration, locale string) string {
+	l := localeFor(locale)
+
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return l.lessThanAMinute
+	}
+
+	units := []durationUnit{
+		{size: 24 * time.Hour, singular: l.day[0], plural: l.day[1]},
+		{size: time.Hour, singular: l.hour[0], plural: l.hour[1]},
+		{size: time.Minute, singular: l.minute[0], plural: l.minute[1]},
+	}
+
+	parts := []string{}
+
+	for _, u := range units {
+		if len(parts) == maxDurationUnits {
+			break
+		}
+
+		n := int64(d / u.size)
+		if n == 0 {
+			// units are only skipped before the first one that is shown, e.g. 1 day and 5 minutes is 1 day
+			if len(parts) > 0 {
+				break
+			}
+
+			continue
+		}
+
+		d -= time.Duration(n) * u.size
+
+		word := u.plural
+		if n == 1 {
+			word = u.singular
+		}
+
+		parts = append(parts, fmt.Sprintf("%d %s", n, word))
+	}
+
+	return strings.Join(parts, " "+l.and+" ")
+}
+
+// LinkExpiry returns when the action link in the email stops working formatted for display in the
+// configured locale, or an empty string when it does not expire
+func (e EmailData) LinkExpiry() string {
+	if e.LinkExpiresAt.IsZero() {
+		return ""
+	}
+
+	return e.LinkExpiresAt.UTC().Format(localeFor(e.Locale).timestamp)
+}
+
+// LinkExpiresIn returns how long the action link in the email is valid for in words in the configured
+// locale, e.g. 15 minutes, or an empty string when it does not expire
+func (e EmailData) LinkExpiresIn() string {
+	switch {
+	case e.LinkTTL > 0:
+		return HumanizeDuration(e.LinkTTL, e.Locale)
+	case !e.LinkExpiresAt.IsZero():
+		return HumanizeDuration(time.Until(e.LinkExpiresAt), e.Locale)
+	}
+
+	return ""
+}
+
+// setLinkExpiry sets when the action link expires and how long it is valid for
+func (e *EmailData) setLinkExpiry(expiresAt time.Time, ttl time.Duration) {
+	e.LinkExpiresAt = expiresAt.Truncate(time.Second)
+	e.LinkTTL = ttl
+}
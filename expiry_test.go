@@ -0,0 +1,91 @@
+package emailtemplates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHumanizeDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		locale   string
+		expected string
+	}{
+		{name: "minutes", duration: 15 * time.Minute, expected: "15 minutes"},
+		{name: "single minute", duration: time.Minute, expected: "1 minute"},
+		{name: "rounded", duration: 59*time.Minute + 40*time.Second, expected: "1 hour"},
+		{name: "less than a minute", duration: 10 * time.Second, expected: "less than a minute"},
+		{name: "hours and minutes", duration: 90 * time.Minute, expected: "1 hour and 30 minutes"},
+		{name: "two largest units", duration: 50*time.Hour + 5*time.Minute, expected: "2 days and 2 hours"},
+		{name: "gap between units", duration: 24*time.Hour + 5*time.Minute, expected: "1 day"},
+		{name: "spanish", duration: 90 * time.Minute, locale: "es-MX", expected: "1 hora y 30 minutos"},
+		{name: "german", duration: 48 * time.Hour, locale: "de_DE", expected: "2 Tage"},
+		{name: "unsupported locale", duration: 2 * time.Hour, locale: "xx", expected: "2 hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HumanizeDuration(tt.duration, tt.locale))
+		})
+	}
+}
+
+func TestLinkExpiry(t *testing.T) {
+	expiresAt := time.Date(2026, time.March, 14, 15, 9, 0, 0, time.UTC)
+
+	e := EmailData{}
+	assert.Empty(t, e.LinkExpiry())
+	assert.Empty(t, e.LinkExpiresIn())
+
+	e.setLinkExpiry(expiresAt, 30*time.Minute)
+	assert.Equal(t, "March 14, 2026 at 15:09 UTC", e.LinkExpiry())
+	assert.Equal(t, "30 minutes", e.LinkExpiresIn())
+
+	e.Locale = "fr"
+	assert.Equal(t, "14/03/2026 15:09 UTC", e.LinkExpiry())
+	assert.Equal(t, "30 minutes", e.LinkExpiresIn())
+}
+
+func TestLinkExpiryOptions(t *testing.T) {
+	cfg := Config{
+		CompanyName: "Test Company",
+		FromEmail:   "no-reply@example.com",
+		URLS: URLConfig{
+			Verify:        "https://console.example.com/verify",
+			PasswordReset: "https://console.example.com/password-reset",
+		},
+	}
+
+	r := Recipient{Email: "test@example.com"}
+
+	email, err := cfg.NewPasswordResetRequestEmail(r, "token", WithLinkTTL(time.Hour))
+	require.NoError(t, err)
+	assert.Contains(t, email.Text, "this link will expire in 1 hour (")
+	assert.Contains(t, email.HTML, "this link will expire in 1 hour (")
+
+	expiresAt := time.Now().Add(2 * time.Hour)
+
+	email, err = cfg.NewVerifyEmail(r, "token", WithLinkExpiry(expiresAt))
+	require.NoError(t, err)
+	assert.Contains(t, email.Text, "This link expires in 2 hours ("+expiresAt.UTC().Format("January 2, 2006 at 15:04 MST")+")")
+
+	// without an expiry the emails keep their default copy
+	email, err = cfg.NewPasswordResetRequestEmail(r, "token")
+	require.NoError(t, err)
+	assert.Contains(t, email.Text, "this link will expire after 15 minutes")
+	assert.NotContains(t, email.Text, "This link expires")
+
+	// an explicit expiry takes precedence over the signed url ttl
+	WithSignedURLs("current", "secret", 24*time.Hour)(&cfg)
+
+	email, err = cfg.NewVerifyEmail(r, "token", WithLinkTTL(time.Hour))
+	require.NoError(t, err)
+
+	claims, err := VerifySignedURL(cfg.SignedURLs, extractLinks(email.HTML)[0], PurposeVerify)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt, time.Minute)
+}
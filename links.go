@@ -0,0 +1,173 @@
+package emailtemplates
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// hrefPattern matches the href attribute of links in rendered html
+var hrefPattern = regexp.MustCompile(`href\s*=\s*["']([^"']*)["']`)
+
+// textLinkPattern matches the links of a text body
+var textLinkPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// textLinkTrailing are trimmed from the links of a text body as they usually end the sentence
+const textLinkTrailing = ".,;:!?)"
+
+// LinkValidation configures the checks applied to every link in the html of a built email
+type LinkValidation struct {
+	// Enabled turns on link validation, invalid links are logged unless Strict is set
+	Enabled bool `koanf:"enabled" json:"enabled" default:"false"`
+	// Strict fails building the email when any link is invalid, it implies Enabled
+	Strict bool `koanf:"strict" json:"strict" default:"false"`
+	// AllowedDomains restricts links to these domains and their subdomains, any domain is allowed when empty
+	AllowedDomains []string `koanf:"alloweddomains" json:"alloweddomains"`
+}
+
+// JoinURL joins path elements onto a base URL, cleaning up duplicate or missing slashes between
+// them; it is available in templates as JoinURL, e.g. {{ JoinURL .URLS.Root "legal/privacy" }}
+func JoinURL(base string, elems ...string) (string, error) {
+	parts := make([]string, 0, len(elems))
+
+	for _, elem := range elems {
+		if elem = strings.Trim(elem, "/"); elem != "" {
+			parts = append(parts, elem)
+		}
+	}
+
+	if base == "" {
+		return strings.Join(parts, "/"), nil
+	}
+
+	joined, err := url.JoinPath(base, parts...)
+	if err != nil {
+		return "", fmt.Errorf("could not join %q onto %q: %w", strings.Join(parts, "/"), base, err)
+	}
+
+	return joined, nil
+}
+
+// extractLinks returns the href of every link in the html, unescaped
+func extractLinks(body string) []string {
+	links := []string{}
+
+	for _, match := range hrefPattern.FindAllStringSubmatch(body, -1) {
+		links = append(links, html.UnescapeString(strings.TrimSpace(match[1])))
+	}
+
+	return links
+}
+
+// rewriteLinks replaces the href of every link in the html with the result of rewrite, which receives
+// the unescaped link. Links that are not changed are left exactly as they were rendered
+func rewriteLinks(body string, rewrite func(link string) string) string {
+	return hrefPattern.ReplaceAllStringFunc(body, func(attr string) string {
+		match := hrefPattern.FindStringSubmatchIndex(attr)
+		raw := attr[match[2]:match[3]]
+		link := html.UnescapeString(strings.TrimSpace(raw))
+
+		rewritten := rewrite(link)
+		if rewritten == link {
+			return attr
+		}
+
+		return attr[:match[2]] + html.EscapeString(rewritten) + attr[match[3]:]
+	})
+}
+
+// rewriteTextLinks replaces the links of a text body with the result of rewrite, punctuation ending a
+// sentence after a link is kept out of it
+func rewriteTextLinks(body string, rewrite func(link string) string) string {
+	return textLinkPattern.ReplaceAllStringFunc(body, func(match string) string {
+		link := strings.TrimRight(match, textLinkTrailing)
+
+		return rewrite(link) + match[len(link):]
+	})
+}
+
+// ValidateLinks checks that every link in the html is absolute, uses https, has no double slashes
+// in its path and points at one of the allowed domains or their subdomains, mailto links are
+// skipped. All problems are returned together in a *LinkValidationError
+func ValidateLinks(body string, allowedDomains []string) error {
+	var problems []LinkProblem
+
+	for _, link := range extractLinks(body) {
+		if reason := checkLink(link, allowedDomains); reason != "" {
+			problems = append(problems, LinkProblem{URL: link, Reason: reason})
+		}
+	}
+
+	if len(problems) > 0 {
+		return &LinkValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// checkLink returns the reason the link is invalid, or an empty string if it is valid
+func checkLink(link string, allowedDomains []string) string {
+	if strings.HasPrefix(strings.ToLower(link), "mailto:") {
+		return ""
+	}
+
+	u, err := url.Parse(link)
+
+	switch {
+	case err != nil:
+		return "could not be parsed"
+	case !u.IsAbs() || u.Host == "":
+		return "is not absolute"
+	case u.Scheme != "https":
+		return "does not use https"
+	case strings.Contains(u.Path, "//"):
+		return "contains a double slash"
+	case !domainAllowed(u.Hostname(), allowedDomains):
+		return "is not on an allowed domain"
+	}
+
+	return ""
+}
+
+// validateLinks applies the configured link validation to the rendered html, invalid links fail
+// the build in strict mode and are otherwise logged
+func (c Config) validateLinks(template, body string) error {
+	if !c.LinkValidation.Enabled && !c.LinkValidation.Strict {
+		return nil
+	}
+
+	err := ValidateLinks(body, c.LinkValidation.AllowedDomains)
+	if err == nil {
+		return nil
+	}
+
+	if c.LinkValidation.Strict {
+		return err
+	}
+
+	log.Warn().Err(err).Str("template", template).Msg("email contains invalid links")
+
+	return nil
+}
+
+// domainAllowed reports whether the host is one of the allowed domains or a subdomain of one
+func domainAllowed(host string, allowedDomains []string) bool {
+	if len(allowedDomains) == 0 {
+		return true
+	}
+
+	host = strings.ToLower(host)
+
+	for _, domain := range allowedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,157 @@
+package emailtemplates
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		elems    []string
+		expected string
+	}{
+		{
+			name:     "missing slash",
+			base:     "https://www.example.com",
+			elems:    []string{"legal/terms-of-service"},
+			expected: "https://www.example.com/legal/terms-of-service",
+		},
+		{
+			name:     "duplicate slashes",
+			base:     "https://www.example.com/",
+			elems:    []string{"//legal/privacy/"},
+			expected: "https://www.example.com/legal/privacy",
+		},
+		{
+			name:     "multiple elements",
+			base:     "https://docs.example.com/v1",
+			elems:    []string{"guides/", "/getting-started"},
+			expected: "https://docs.example.com/v1/guides/getting-started",
+		},
+		{
+			name:     "empty base",
+			base:     "",
+			elems:    []string{"/legal/privacy"},
+			expected: "legal/privacy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			joined, err := JoinURL(tt.base, tt.elems...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, joined)
+		})
+	}
+
+	_, err := JoinURL("://invalid", "legal")
+	require.Error(t, err)
+}
+
+func TestValidateLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		allowed  []string
+		problems []string
+	}{
+		{
+			name:    "valid links",
+			html:    `<a href="https://www.example.com/legal/privacy">Privacy</a> <a href='https://console.example.com/verify?token=abc&amp;next=1'>Verify</a>`,
+			allowed: []string{"example.com"},
+		},
+		{
+			name: "mailto is skipped",
+			html: `<a href="mailto:support@example.com">Support</a>`,
+		},
+		{
+			name:     "relative link",
+			html:     `<a href="/legal/privacy">Privacy</a>`,
+			problems: []string{"is not absolute"},
+		},
+		{
+			name:     "http link",
+			html:     `<a href="http://www.example.com">Home</a>`,
+			problems: []string{"does not use https"},
+		},
+		{
+			name:     "double slash",
+			html:     `<a href="https://www.example.com//legal/privacy/">Privacy</a>`,
+			problems: []string{"contains a double slash"},
+		},
+		{
+			name:     "domain not allowed",
+			html:     `<a href="https://www.example.com">Home</a> <a href="https://notexample.com">Other</a>`,
+			allowed:  []string{"example.com"},
+			problems: []string{"is not on an allowed domain"},
+		},
+		{
+			name:     "all problems are returned",
+			html:     `<a href="legal">Terms</a> <a href="http://www.example.com">Home</a>`,
+			problems: []string{"is not absolute", "does not use https"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLinks(tt.html, tt.allowed)
+			if len(tt.problems) == 0 {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidLink)
+
+			var linkErr *LinkValidationError
+
+			require.True(t, errors.As(err, &linkErr))
+			require.Len(t, linkErr.Problems, len(tt.problems))
+
+			for i, reason := range tt.problems {
+				assert.Equal(t, reason, linkErr.Problems[i].Reason)
+			}
+		})
+	}
+}
+
+func TestLinkValidationStrict(t *testing.T) {
+	data := VerifyEmailData{
+		EmailData: EmailData{
+			Config: Config{
+				CompanyName: "Test Company",
+				FromEmail:   "no-reply@example.com",
+				URLS: URLConfig{
+					Root:    "https://www.example.com",
+					Product: "https://console.example.com",
+					Verify:  "https://console.example.com/verify?token=token",
+				},
+			},
+			Recipient: Recipient{Email: "test@example.com"},
+		},
+	}
+
+	WithLinkValidation(true, "example.com")(&data.Config)
+
+	email, err := verify(data)
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, `href="https://www.example.com/legal/privacy"`)
+	assert.Contains(t, email.HTML, `href="https://www.example.com/legal/terms-of-service"`)
+
+	data.URLS.Privacy = "https://www.example.com//privacy"
+
+	_, err = verify(data)
+	require.ErrorIs(t, err, ErrInvalidLink)
+
+	// without strict mode the invalid link is only logged
+	data.LinkValidation.Strict = false
+
+	email, err = verify(data)
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, `href="https://www.example.com//privacy"`)
+}
@@ -0,0 +1,279 @@
+// Package lint checks a directory of email templates for problems that would otherwise only
+// show up when a message fails to render, such as missing partials or unknown fields
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/theopenlane/emailtemplates"
+)
+
+const (
+	// partialsDir is the directory next to the templates that holds the partials
+	partialsDir = "partials"
+
+	htmlExt = ".html"
+	textExt = ".txt"
+)
+
+// Severity of an issue
+type Severity string
+
+const (
+	// SeverityError issues break rendering or the delivered email
+	SeverityError Severity = "error"
+	// SeverityWarning issues are likely mistakes that still render
+	SeverityWarning Severity = "warning"
+)
+
+// Rules reported by the linter
+const (
+	// RuleParse is reported when a template cannot be parsed
+	RuleParse = "parse"
+	// RuleMissingText is reported when an html template has no text twin
+	RuleMissingText = "missing-text"
+	// RuleMissingBlock is reported when a required block is not defined
+	RuleMissingBlock = "missing-block"
+	// RuleMissingPartial is reported when a referenced partial does not exist
+	RuleMissingPartial = "missing-partial"
+	// RuleUnknownField is reported when a field does not exist on the data type of the template
+	RuleUnknownField = "unknown-field"
+	// RuleRelativeLink is reported when a link is not absolute
+	RuleRelativeLink = "relative-link"
+	// RuleMissingAlt is reported when an image has no alt text
+	RuleMissingAlt = "missing-alt"
+)
+
+var (
+	// requiredBlocks are the blocks a template must define when its layout renders them, by extension
+	requiredBlocks = map[string][]string{
+		htmlExt: {"title", "preheader", "content"},
+		textExt: {"content"},
+	}
+
+	hrefPattern     = regexp.MustCompile(`href\s*=\s*["']([^"']*)["']`)
+	imgPattern      = regexp.MustCompile(`(?is)<img\b[^>]*>`)
+	altPattern      = regexp.MustCompile(`(?i)\balt\s*=`)
+	absoluteLink    = regexp.MustCompile(`^(https?://|mailto:|tel:|#)`)
+	templateActions = regexp.MustCompile(`(?s){{.*?}}`)
+)
+
+// Issue is a single problem found in a template
+type Issue struct {
+	// File is the path of the template with the problem
+	File string `json:"file"`
+	// Line is the line number of the problem, zero when it applies to the whole file
+	Line int `json:"line,omitempty"`
+	// Rule is the name of the rule that was broken
+	Rule string `json:"rule"`
+	// Severity of the issue
+	Severity Severity `json:"severity"`
+	// Message describes the problem
+	Message string `json:"message"`
+}
+
+// String returns the issue in the file:line: severity message (rule) format
+func (i Issue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d", i.File, i.Line)
+	}
+
+	return fmt.Sprintf("%s: %s %s (%s)", location, i.Severity, i.Message, i.Rule)
+}
+
+// HasErrors reports whether any of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// linter holds the parsed templates of a directory
+type linter struct {
+	dir      string
+	files    []string
+	sources  map[string]string
+	trees    map[string]map[string]*parse.Tree
+	partials map[string]*parse.Tree
+	issues   []Issue
+}
+
+// Lint checks every template in dir and returns the issues found, sorted by file and line.
+// Templates with samples registered in the emailtemplates package, which includes every built
+// in email, also have their field references checked against the type of the sample data
+func Lint(dir string) ([]Issue, error) {
+	l := &linter{
+		dir:      dir,
+		issues:   []Issue{},
+		sources:  map[string]string{},
+		trees:    map[string]map[string]*parse.Tree{},
+		partials: map[string]*parse.Tree{},
+	}
+
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+
+	for _, file := range l.files {
+		l.lintFile(file)
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].File != l.issues[j].File {
+			return l.issues[i].File < l.issues[j].File
+		}
+
+		return l.issues[i].Line < l.issues[j].Line
+	})
+
+	return l.issues, nil
+}
+
+// load parses the templates and partials in the directory
+func (l *linter) load() error {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != htmlExt && ext != textExt) {
+			continue
+		}
+
+		l.files = append(l.files, entry.Name())
+	}
+
+	partials, err := filepath.Glob(filepath.Join(l.dir, partialsDir, "*"))
+	if err != nil {
+		return err
+	}
+
+	for _, partial := range partials {
+		trees, ok := l.parse(partial)
+		if !ok {
+			continue
+		}
+
+		for name, tree := range trees {
+			if _, exists := l.partials[name]; !exists || name == filepath.Base(partial) {
+				l.partials[name] = tree
+			}
+		}
+	}
+
+	for _, file := range l.files {
+		if trees, ok := l.parse(filepath.Join(l.dir, file)); ok {
+			l.trees[file] = trees
+		}
+	}
+
+	return nil
+}
+
+// parse parses a single file into its named trees, the file's own tree is keyed by the file name.
+// Functions are not checked so templates using custom functions can still be linted
+func (l *linter) parse(path string) (map[string]*parse.Tree, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		l.report(path, 0, RuleParse, SeverityError, err.Error())
+		return nil, false
+	}
+
+	l.sources[path] = string(content)
+
+	name := filepath.Base(path)
+	trees := map[string]*parse.Tree{}
+
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+
+	if _, err := tree.Parse(string(content), "", "", trees); err != nil {
+		l.report(path, 0, RuleParse, SeverityError, err.Error())
+		return nil, false
+	}
+
+	return trees, true
+}
+
+// lintFile runs every rule against a single template
+func (l *linter) lintFile(file string) {
+	path := filepath.Join(l.dir, file)
+	ext := filepath.Ext(file)
+
+	if ext == htmlExt {
+		twin := strings.TrimSuffix(file, htmlExt) + textExt
+		if _, err := os.Stat(filepath.Join(l.dir, twin)); err != nil {
+			l.report(path, 0, RuleMissingText, SeverityError, fmt.Sprintf("%s has no text version %s", file, twin))
+		}
+
+		l.lintMarkup(path)
+	}
+
+	trees, ok := l.trees[file]
+	if !ok {
+		return
+	}
+
+	w := &walker{linter: l, path: path, trees: trees, visited: map[string]bool{}, invoked: map[string]bool{}}
+
+	if data, err := emailtemplates.SampleData(strings.TrimSuffix(file, ext), emailtemplates.DefaultSample); err == nil {
+		if t := reflect.TypeOf(data); t.Kind() == reflect.Struct {
+			w.root = t
+		}
+	}
+
+	w.walkTree(trees[file], w.root)
+
+	// blocks are only required when the layout the template uses renders them
+	for _, block := range requiredBlocks[ext] {
+		if _, ok := trees[block]; !ok && w.invoked[block] {
+			l.report(path, 0, RuleMissingBlock, SeverityError, fmt.Sprintf("block %q rendered by the layout is not defined", block))
+		}
+	}
+}
+
+// lintMarkup checks the links and images of an html template, dynamic values are skipped
+func (l *linter) lintMarkup(path string) {
+	source := l.sources[path]
+
+	for _, match := range hrefPattern.FindAllStringSubmatchIndex(source, -1) {
+		link := source[match[2]:match[3]]
+		if strings.HasPrefix(strings.TrimSpace(link), "{{") || absoluteLink.MatchString(link) {
+			continue
+		}
+
+		l.report(path, lineOf(source, match[0]), RuleRelativeLink, SeverityError, fmt.Sprintf("link %q is not absolute", link))
+	}
+
+	for _, match := range imgPattern.FindAllStringIndex(source, -1) {
+		// actions inside the tag, e.g. conditional attributes, are not attributes themselves
+		tag := templateActions.ReplaceAllString(source[match[0]:match[1]], "")
+		if !altPattern.MatchString(tag) {
+			l.report(path, lineOf(source, match[0]), RuleMissingAlt, SeverityWarning, "image has no alt text")
+		}
+	}
+}
+
+// report records an issue
+func (l *linter) report(path string, line int, rule string, severity Severity, message string) {
+	l.issues = append(l.issues, Issue{File: path, Line: line, Rule: rule, Severity: severity, Message: message})
+}
+
+// lineOf returns the line number of the offset in the source
+func lineOf(source string, offset int) int {
+	return strings.Count(source[:offset], "\n") + 1
+}
@@ -0,0 +1,87 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintClean(t *testing.T) {
+	issues, err := Lint("testdata/clean")
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestLintBuiltinTemplates(t *testing.T) {
+	issues, err := Lint("../templates")
+	require.NoError(t, err)
+
+	type found struct {
+		file string
+		rule string
+	}
+
+	got := []found{}
+	for _, i := range issues {
+		got = append(got, found{file: i.File, rule: i.Rule})
+	}
+
+	// the built in templates are linted as they ship, these blocks are not defined yet
+	assert.ElementsMatch(t, []found{
+		{"../templates/questionnaire_auth.html", RuleMissingBlock},
+		{"../templates/trust_center_auth.html", RuleMissingBlock},
+		{"../templates/trust_center_nda_request.html", RuleMissingBlock},
+		{"../templates/trust_center_nda_request.txt", RuleMissingBlock},
+		{"../templates/trust_center_nda_signed.html", RuleMissingBlock},
+		{"../templates/trust_center_nda_signed.txt", RuleMissingBlock},
+	}, got)
+}
+
+func TestLint(t *testing.T) {
+	issues, err := Lint("testdata/broken")
+	require.NoError(t, err)
+	assert.True(t, HasErrors(issues))
+
+	type found struct {
+		file string
+		line int
+		rule string
+	}
+
+	got := []found{}
+	for _, i := range issues {
+		got = append(got, found{file: i.File, line: i.Line, rule: i.Rule})
+	}
+
+	assert.ElementsMatch(t, []found{
+		{"testdata/broken/invite.html", 0, RuleMissingBlock},
+		{"testdata/broken/invite.txt", 0, RuleMissingBlock},
+		{"testdata/broken/partials/base.html", 6, RuleUnknownField},
+		{"testdata/broken/partials/base.html", 6, RuleUnknownField},
+		{"testdata/broken/welcome.html", 0, RuleMissingText},
+		{"testdata/broken/welcome.html", 7, RuleMissingAlt},
+		{"testdata/broken/welcome.html", 9, RuleRelativeLink},
+		{"testdata/broken/welcome.html", 12, RuleUnknownField},
+		{"testdata/broken/welcome.html", 13, RuleUnknownField},
+		{"testdata/broken/welcome.html", 14, RuleMissingPartial},
+	}, got)
+
+	// the field inside the with block is resolved against the recipient
+	for _, i := range issues {
+		if i.Line == 13 {
+			assert.Contains(t, i.Message, ".MiddleName does not exist on Recipient")
+		}
+	}
+}
+
+func TestLintMissingDir(t *testing.T) {
+	_, err := Lint("testdata/does-not-exist")
+	require.Error(t, err)
+}
+
+func TestIssueString(t *testing.T) {
+	i := Issue{File: "welcome.html", Line: 3, Rule: RuleMissingAlt, Severity: SeverityWarning, Message: "image has no alt text"}
+	assert.Equal(t, "welcome.html:3: warning image has no alt text (missing-alt)", i.String())
+	assert.False(t, HasErrors([]Issue{i}))
+}
@@ -0,0 +1,241 @@
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// walker follows a template through the partials it invokes, tracking the type of dot so
+// field references can be checked against the data type of the template
+type walker struct {
+	*linter
+
+	path    string
+	trees   map[string]*parse.Tree
+	root    reflect.Type
+	visited map[string]bool
+	invoked map[string]bool
+}
+
+// walkTree walks a parsed template with dot set to the provided type, nil when the type is unknown
+func (w *walker) walkTree(tree *parse.Tree, dot reflect.Type) {
+	if tree == nil || tree.Root == nil {
+		return
+	}
+
+	w.walk(tree, tree.Root, dot)
+}
+
+// walk checks a node and its children
+func (w *walker) walk(tree *parse.Tree, node parse.Node, dot reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			w.walk(tree, child, dot)
+		}
+	case *parse.ActionNode:
+		w.checkPipe(tree, n.Pipe, dot)
+	case *parse.IfNode:
+		w.checkPipe(tree, n.Pipe, dot)
+		w.walk(tree, n.List, dot)
+		w.walk(tree, n.ElseList, dot)
+	case *parse.WithNode:
+		w.checkPipe(tree, n.Pipe, dot)
+		w.walk(tree, n.List, w.pipeType(n.Pipe, dot))
+		w.walk(tree, n.ElseList, dot)
+	case *parse.RangeNode:
+		w.checkPipe(tree, n.Pipe, dot)
+		w.walk(tree, n.List, elemType(w.pipeType(n.Pipe, dot)))
+		w.walk(tree, n.ElseList, dot)
+	case *parse.TemplateNode:
+		w.walkTemplate(tree, n, dot)
+	}
+}
+
+// walkTemplate follows a template invocation into the invoked template or partial
+func (w *walker) walkTemplate(tree *parse.Tree, n *parse.TemplateNode, dot reflect.Type) {
+	var arg reflect.Type
+
+	if n.Pipe != nil {
+		w.checkPipe(tree, n.Pipe, dot)
+		arg = w.pipeType(n.Pipe, dot)
+	}
+
+	if _, own := w.trees[tree.ParseName]; !own {
+		w.invoked[n.Name] = true
+	}
+
+	sub, ok := w.trees[n.Name]
+	if !ok {
+		sub, ok = w.partials[n.Name]
+	}
+
+	if !ok {
+		w.reportNode(tree, n, RuleMissingPartial, SeverityError,
+			fmt.Sprintf("template %q does not exist, add it to the %s directory", n.Name, partialsDir))
+
+		return
+	}
+
+	key := fmt.Sprintf("%s:%v", n.Name, arg)
+	if w.visited[key] {
+		return
+	}
+
+	w.visited[key] = true
+
+	w.walkTree(sub, arg)
+}
+
+// checkPipe checks the field references in every command of the pipeline
+func (w *walker) checkPipe(tree *parse.Tree, pipe *parse.PipeNode, dot reflect.Type) {
+	if pipe == nil {
+		return
+	}
+
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				w.resolve(tree, a, dot, a.Ident)
+			case *parse.VariableNode:
+				if len(a.Ident) > 1 && a.Ident[0] == "$" {
+					w.resolve(tree, a, w.root, a.Ident[1:])
+				}
+			case *parse.PipeNode:
+				w.checkPipe(tree, a, dot)
+			}
+		}
+	}
+}
+
+// pipeType returns the type a pipeline evaluates to when it is a single field or dot, nil otherwise
+func (w *walker) pipeType(pipe *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+
+	switch a := pipe.Cmds[0].Args[0].(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		t, _ := lookup(dot, a.Ident)
+		return t
+	case *parse.VariableNode:
+		if a.Ident[0] == "$" {
+			t, _ := lookup(w.root, a.Ident[1:])
+			return t
+		}
+	}
+
+	return nil
+}
+
+// resolve reports the field chain if it does not exist on the type
+func (w *walker) resolve(tree *parse.Tree, node parse.Node, t reflect.Type, idents []string) {
+	if t == nil {
+		return
+	}
+
+	if _, bad := lookup(t, idents); bad != "" {
+		w.reportNode(tree, node, RuleUnknownField, SeverityError,
+			fmt.Sprintf("field %s does not exist on %s when rendering %s", bad, t.Name(), filepath.Base(w.path)))
+	}
+}
+
+// reportNode reports an issue at the position of the node, issues in partials are reported
+// against the partial file and only once regardless of how many templates include it
+func (w *walker) reportNode(tree *parse.Tree, node parse.Node, rule string, severity Severity, message string) {
+	path := w.path
+	if _, ok := w.trees[tree.ParseName]; !ok {
+		path = filepath.Join(w.dir, partialsDir, tree.ParseName)
+	}
+
+	line := 0
+
+	if location, _ := tree.ErrorContext(node); location != "" {
+		if parts := strings.Split(location, ":"); len(parts) > 1 {
+			line, _ = strconv.Atoi(parts[1])
+		}
+	}
+
+	for _, existing := range w.issues {
+		if existing.File == path && existing.Line == line && existing.Message == message {
+			return
+		}
+	}
+
+	w.report(path, line, rule, severity, message)
+}
+
+// lookup resolves a chain of field or method names on the type. It returns the resulting type, nil
+// when it cannot be known statically e.g. for maps and interfaces, and the chain up to and including
+// the first name that does not exist
+func lookup(t reflect.Type, idents []string) (reflect.Type, string) {
+	for i, ident := range idents {
+		if t == nil {
+			return nil, ""
+		}
+
+		if m, ok := t.MethodByName(ident); ok {
+			t = returnType(m.Type)
+			continue
+		}
+
+		if t.Kind() != reflect.Pointer {
+			if m, ok := reflect.PointerTo(t).MethodByName(ident); ok {
+				t = returnType(m.Type)
+				continue
+			}
+		}
+
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Map, reflect.Interface:
+			return nil, ""
+		case reflect.Struct:
+			if f, ok := t.FieldByName(ident); ok && f.IsExported() {
+				t = f.Type
+				continue
+			}
+		}
+
+		return nil, "." + strings.Join(idents[:i+1], ".")
+	}
+
+	return t, ""
+}
+
+// returnType returns the first result of a method, nil if it has none
+func returnType(method reflect.Type) reflect.Type {
+	if method.NumOut() == 0 {
+		return nil
+	}
+
+	return method.Out(0)
+}
+
+// elemType returns the type of the elements ranged over, nil when unknown
+func elemType(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return t.Elem()
+	}
+
+	return nil
+}
@@ -0,0 +1,365 @@
+package emailtemplates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envSeparator separates the prefix and the keys of the config in environment variable names
+const envSeparator = "_"
+
+// durationType is set from duration strings such as 24h rather than integers
+var durationType = reflect.TypeFor[time.Duration]()
+
+// FromEnv creates a new email config from the environment variables with the prefix, following the koanf
+// tags of the config with underscores between the keys, e.g. EMAIL_URLS_VERIFY sets URLS.Verify and
+// EMAIL_SIGNEDURLS_KEYS_Key2026 sets the Key2026 signing key. Field names match in any case while map
+// keys are kept as written. Lists are comma separated
+func FromEnv(prefix string, options ...Option) (*Config, error) {
+	prefix = strings.TrimSuffix(prefix, envSeparator) + envSeparator
+	values := map[string]any{}
+
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+
+		setPath(values, strings.Split(rest, envSeparator), value)
+	}
+
+	return FromMap(values, options...)
+}
+
+// FromFile creates a new email config from a YAML or JSON file, following the koanf tags of the config
+func FromFile(path string, options ...Option) (*Config, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedConfigFormat, ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file %q: %w", path, err)
+	}
+
+	return FromMap(values, options...)
+}
+
+// FromMap creates a new email config from the values keyed by the koanf tags of the config, nested
+// configs are nested maps. The default tags are set first, the options are applied after the values and
+// the config is validated like New
+func FromMap(values map[string]any, options ...Option) (*Config, error) {
+	c := &Config{}
+	v := validator{}
+
+	setDefaults(&v, "", reflect.ValueOf(c).Elem())
+	decodeValue(&v, "", reflect.ValueOf(c).Elem(), values)
+
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// setPath sets the value in the nested maps at the keys, replacing values that are in the way
+func setPath(values map[string]any, keys []string, value any) {
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := values[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			values[key] = next
+		}
+
+		values = next
+	}
+
+	values[keys[len(keys)-1]] = value
+}
+
+// fieldPath joins the path of the parent and the name of the field
+func fieldPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+
+	return parent + "." + name
+}
+
+// configKey returns the key of the struct field, the koanf tag or the lower case name of the field
+func configKey(f reflect.StructField) string {
+	if key := f.Tag.Get("koanf"); key != "" {
+		return key
+	}
+
+	return strings.ToLower(f.Name)
+}
+
+// setDefaults sets the zero fields of the struct to their default tags, walking nested structs
+func setDefaults(v *validator, field string, dst reflect.Value) {
+	t := dst.Type()
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get("koanf") == "-" {
+			continue
+		}
+
+		path := fieldPath(field, f.Name)
+
+		switch def := f.Tag.Get("default"); {
+		case f.Type.Kind() == reflect.Struct:
+			setDefaults(v, path, dst.Field(i))
+		case def != "" && dst.Field(i).IsZero():
+			decodeValue(v, path, dst.Field(i), def)
+		}
+	}
+}
+
+// decodeValue sets the value of the field from the raw value, recording a field error when it cannot be converted
+func decodeValue(v *validator, field string, dst reflect.Value, raw any) {
+	if raw == nil {
+		return
+	}
+
+	if err := decode(v, field, dst, raw); err != nil {
+		v.add(field, fmt.Errorf("%w: %w", ErrInvalidConfigValue, err))
+	}
+}
+
+// decode sets the value of the field from the raw value, structs and maps are decoded from maps and
+// lists from lists or comma separated strings
+func decode(v *validator, field string, dst reflect.Value, raw any) error {
+	if dst.Type() == durationType {
+		return decodeDuration(dst, raw)
+	}
+
+	switch dst.Kind() {
+	case reflect.Struct:
+		return decodeStruct(v, field, dst, raw)
+	case reflect.Map:
+		return decodeMap(v, field, dst, raw)
+	case reflect.Slice:
+		return decodeSlice(v, field, dst, raw)
+	case reflect.String:
+		s, ok := scalar(raw)
+		if !ok {
+			return typeError(raw, dst)
+		}
+
+		dst.SetString(s)
+	case reflect.Bool:
+		s, ok := scalar(raw)
+		if !ok {
+			return typeError(raw, dst)
+		}
+
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s, ok := scalar(raw)
+		if !ok {
+			return typeError(raw, dst)
+		}
+
+		n, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		dst.SetInt(n)
+	default:
+		return typeError(raw, dst)
+	}
+
+	return nil
+}
+
+// decodeStruct sets the fields of the struct from the map, matching the keys ignoring case
+func decodeStruct(v *validator, field string, dst reflect.Value, raw any) error {
+	values, ok := raw.(map[string]any)
+	if !ok {
+		return typeError(raw, dst)
+	}
+
+	t := dst.Type()
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get("koanf") == "-" {
+			continue
+		}
+
+		for key, value := range values {
+			if strings.EqualFold(key, configKey(f)) {
+				decodeValue(v, fieldPath(field, f.Name), dst.Field(i), value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeMap sets the entries of the map from the map, struct values start from their default tags
+func decodeMap(v *validator, field string, dst reflect.Value, raw any) error {
+	values, ok := raw.(map[string]any)
+	if !ok || dst.Type().Key().Kind() != reflect.String {
+		return typeError(raw, dst)
+	}
+
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMap(dst.Type()))
+	}
+
+	elemType := dst.Type().Elem()
+	entries := map[string]any{}
+
+	flattenEntries(entries, "", values, elemType)
+
+	for key, value := range entries {
+		elem := reflect.New(elemType).Elem()
+		path := fieldPath(field, key)
+
+		if elemType.Kind() == reflect.Struct {
+			setDefaults(v, path, elem)
+		}
+
+		decodeValue(v, path, elem, value)
+		dst.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+	}
+
+	return nil
+}
+
+// flattenEntries adds the entries of the map, joining the keys of nested maps that are not values of the
+// map with underscores, as environment variables split keys such as trust_center_auth into nested maps
+func flattenEntries(entries map[string]any, prefix string, values map[string]any, elemType reflect.Type) {
+	for key, value := range values {
+		key = prefix + key
+
+		nested, ok := value.(map[string]any)
+		if !ok || elemType.Kind() == reflect.Map || (elemType.Kind() == reflect.Struct && hasField(elemType, nested)) {
+			entries[key] = value
+
+			continue
+		}
+
+		flattenEntries(entries, key+envSeparator, nested, elemType)
+	}
+}
+
+// hasField reports whether any key of the map is a field of the struct
+func hasField(t reflect.Type, values map[string]any) bool {
+	for i := range t.NumField() {
+		for key := range values {
+			if strings.EqualFold(key, configKey(t.Field(i))) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// decodeSlice sets the slice from the list, or from the comma separated string
+func decodeSlice(v *validator, field string, dst reflect.Value, raw any) error {
+	var items []any
+
+	switch value := raw.(type) {
+	case []any:
+		items = value
+	case string:
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	default:
+		return typeError(raw, dst)
+	}
+
+	slice := reflect.MakeSlice(dst.Type(), len(items), len(items))
+
+	for i, item := range items {
+		decodeValue(v, fmt.Sprintf("%s[%d]", field, i), slice.Index(i), item)
+	}
+
+	dst.Set(slice)
+
+	return nil
+}
+
+// decodeDuration sets the duration from a duration string such as 24h, or from a number of nanoseconds
+func decodeDuration(dst reflect.Value, raw any) error {
+	s, ok := scalar(raw)
+	if !ok {
+		return typeError(raw, dst)
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		dst.SetInt(n)
+
+		return nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	dst.SetInt(int64(d))
+
+	return nil
+}
+
+// scalar returns the string form of a string, number or boolean value
+func scalar(raw any) (string, bool) {
+	switch value := raw.(type) {
+	case string:
+		return value, true
+	case bool, int, int64, uint64:
+		return fmt.Sprint(value), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// typeError returns an error for a raw value that cannot be decoded into the field
+func typeError(raw any, dst reflect.Value) error {
+	return fmt.Errorf("cannot use %T as %s", raw, dst.Type())
+}
@@ -0,0 +1,118 @@
+package emailtemplates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromMap(t *testing.T) {
+	cfg, err := FromMap(map[string]any{
+		"CompanyName":    "Openlane",
+		"companyaddress": "5150 Broadway St",
+		"fromemail":      "no-reply@mail.theopenlane.io",
+		"displaynames":   true,
+		"urls":           map[string]any{"invite": "https://console.theopenlane.io/invite"},
+		"senderprofiles": map[string]any{
+			"billing": map[string]any{
+				"fromemail":  "billing@theopenlane.io",
+				"templates":  []any{"verify_billing"},
+				"categories": "security, transactional",
+			},
+		},
+	}, WithSupportEmail("support@theopenlane.io"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Openlane", cfg.CompanyName)
+	assert.True(t, cfg.DisplayNames)
+	assert.Equal(t, "https://console.theopenlane.io/invite", cfg.URLS.Invite)
+	assert.Equal(t, "support@theopenlane.io", cfg.SupportEmail)
+	assert.Equal(t, []string{"verify_billing"}, cfg.SenderProfiles["billing"].Templates)
+	assert.Equal(t, []Category{CategorySecurity, CategoryTransactional}, cfg.SenderProfiles["billing"].Categories)
+
+	// default tags are set
+	assert.Equal(t, "en", cfg.Locale)
+	assert.Equal(t, ShortLinkMode("both"), cfg.ShortLinkMode)
+	assert.Equal(t, 24*time.Hour, cfg.SignedURLs.TTL)
+}
+
+func TestFromMapErrors(t *testing.T) {
+	_, err := FromMap(map[string]any{
+		"companyname":  "Openlane",
+		"displaynames": "sometimes",
+		"year":         []any{2024},
+		"signedurls":   map[string]any{"ttl": "a day"},
+	})
+	require.ErrorIs(t, err, ErrInvalidConfigValue)
+
+	var verrs *ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+	assert.ElementsMatch(t, []string{"DisplayNames", "Year", "SignedURLs.TTL"}, verrs.Fields())
+
+	// loaded configs are validated like New
+	_, err = FromMap(map[string]any{"companyname": "Openlane"})
+	require.ErrorAs(t, err, &verrs)
+	assert.Equal(t, []string{"CompanyAddress", "FromEmail"}, verrs.Fields())
+}
+
+func TestFromFile(t *testing.T) {
+	cfg, err := FromFile("testdata/config/email.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Openlane", cfg.CompanyName)
+	assert.Equal(t, "https://console.theopenlane.io/verify", cfg.URLS.Verify)
+	assert.True(t, cfg.SignedURLs.Enabled)
+	assert.Equal(t, time.Hour, cfg.SignedURLs.TTL)
+	assert.Equal(t, map[string]string{"v1": "secret"}, cfg.SignedURLs.Keys)
+	assert.Equal(t, SenderProfile{FromEmail: "news@mail.theopenlane.io", Categories: []Category{CategoryMarketing}}, cfg.SenderProfiles["marketing"])
+	assert.Equal(t, "{{ .OrganizationName }} Trust", cfg.SenderNames["trust_center_auth"])
+	assert.Equal(t, UTMParams{Source: "email", Medium: "email", Campaign: "onboarding"}, cfg.UTM.Templates["welcome"])
+	assert.Equal(t, []Category{CategorySecurity, CategoryTransactional}, cfg.Tracking.ExcludeCategories)
+
+	cfg, err = FromFile("testdata/config/email.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2024, cfg.Year)
+	assert.Equal(t, []string{"archive@theopenlane.io"}, cfg.TemplateRecipients["invite"].BCC)
+
+	path := filepath.Join(t.TempDir(), "email.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`companyname = "Openlane"`), 0o600))
+
+	_, err = FromFile(path)
+	require.ErrorIs(t, err, ErrUnsupportedConfigFormat)
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("EMAIL_COMPANYNAME", "Openlane")
+	t.Setenv("EMAIL_COMPANYADDRESS", "5150 Broadway St")
+	t.Setenv("EMAIL_FROMEMAIL", "no-reply@mail.theopenlane.io")
+	t.Setenv("EMAIL_URLS_VERIFY", "https://console.theopenlane.io/verify")
+	t.Setenv("EMAIL_SENDERPROFILES_marketing_FROMEMAIL", "news@mail.theopenlane.io")
+	t.Setenv("EMAIL_SENDERPROFILES_marketing_CATEGORIES", "marketing")
+	t.Setenv("EMAIL_SENDERNAMES_trust_center_auth", "Trust Center")
+	t.Setenv("EMAIL_UTM_TEMPLATES_password_reset_request_CAMPAIGN", "reset")
+	t.Setenv("EMAIL_SIGNEDURLS_ENABLED", "true")
+	t.Setenv("EMAIL_SIGNEDURLS_KEYID", "Key2026")
+	t.Setenv("EMAIL_SIGNEDURLS_KEYS_Key2026", "secret")
+	t.Setenv("EMAIL_signedurls_keys_key2025", "retired")
+	t.Setenv("EMAIL_TRACKING_EXCLUDETEMPLATES", "verify_email,invite")
+	t.Setenv("EMAIL_SIGNEDURLS_TTL", "2h")
+	t.Setenv("OTHER_COMPANYNAME", "Other")
+
+	cfg, err := FromEnv("EMAIL")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Openlane", cfg.CompanyName)
+	assert.Equal(t, "https://console.theopenlane.io/verify", cfg.URLS.Verify)
+	assert.Equal(t, SenderProfile{FromEmail: "news@mail.theopenlane.io", Categories: []Category{CategoryMarketing}}, cfg.SenderProfiles["marketing"])
+	assert.Equal(t, "Trust Center", cfg.SenderNames["trust_center_auth"])
+	assert.Equal(t, "reset", cfg.UTM.Templates["password_reset_request"].Campaign)
+	assert.Equal(t, []string{"verify_email", "invite"}, cfg.Tracking.ExcludeTemplates)
+	assert.Equal(t, 2*time.Hour, cfg.SignedURLs.TTL)
+	assert.Equal(t, "Key2026", cfg.SignedURLs.KeyID)
+	assert.Equal(t, map[string]string{"Key2026": "secret", "key2025": "retired"}, cfg.SignedURLs.Keys)
+}
@@ -0,0 +1,94 @@
+package emailtemplates
+
+import (
+	"sort"
+	"time"
+
+	"github.com/theopenlane/newman"
+)
+
+// SendAtTag is the name of the message tag that holds the time an email is scheduled to be sent, in
+// RFC 3339, for senders that support scheduling
+const SendAtTag = "send_at"
+
+// Priority is the importance of an email shown by mail clients
+type Priority string
+
+// Priorities of the emails, emails without one are sent without priority headers
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// priorityHeaders are the X-Priority and Importance headers of each priority
+var priorityHeaders = map[Priority][2]string{
+	PriorityHigh:   {"1 (Highest)", "high"},
+	PriorityNormal: {"3 (Normal)", "normal"},
+	PriorityLow:    {"5 (Lowest)", "low"},
+}
+
+// reservedTags are set by Build and cannot be set with WithTag
+var reservedTags = map[string]bool{
+	TemplateTag:  true,
+	CategoryTag:  true,
+	MessageIDTag: true,
+	SendAtTag:    true,
+}
+
+// messageTags returns the tags of the email, the reserved tags first and then the tags set with
+// WithTag sorted by name
+func (e EmailData) messageTags() []newman.Tag {
+	tags := []newman.Tag{}
+
+	for _, tag := range [][2]string{
+		{TemplateTag, e.Template},
+		{CategoryTag, string(e.Category)},
+		{MessageIDTag, e.MessageID},
+	} {
+		if tag[1] != "" {
+			tags = append(tags, newman.Tag{Name: tag[0], Value: tag[1]})
+		}
+	}
+
+	if !e.SendAt.IsZero() {
+		tags = append(tags, newman.Tag{Name: SendAtTag, Value: e.SendAt.UTC().Format(time.RFC3339)})
+	}
+
+	names := make([]string, 0, len(e.Tags))
+
+	for name := range e.Tags {
+		if !reservedTags[name] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		tags = append(tags, newman.Tag{Name: name, Value: e.Tags[name]})
+	}
+
+	return tags
+}
+
+// messageHeaders returns the headers set with WithHeader along with the priority and return path headers, the
+// headers set by Build are added after and take precedence
+func (e EmailData) messageHeaders() map[string]string {
+	headers := make(map[string]string, len(e.Headers))
+
+	for k, v := range e.Headers {
+		headers[k] = v
+	}
+
+	if values, ok := priorityHeaders[e.Priority]; ok {
+		headers["X-Priority"] = values[0]
+		headers["Importance"] = values[1]
+	}
+
+	if returnPath := e.senderProfile().ReturnPath; returnPath != "" {
+		headers[returnPathHeader] = returnPath
+	}
+
+	return headers
+}
@@ -0,0 +1,63 @@
+package emailtemplates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theopenlane/newman"
+)
+
+func TestMessageOptions(t *testing.T) {
+	sendAt := time.Date(2026, time.March, 14, 15, 0, 0, 0, time.FixedZone("CST", -6*60*60))
+
+	email, err := categoryConfig().NewSubscriberEmail(Recipient{Email: "test@example.com"}, "Meow Inc.", "abc",
+		WithHeader("X-Campaign", "spring"),
+		WithHeader(ListUnsubscribeHeader, "<https://evil.example.com>"),
+		WithTag("tenant", "meow"),
+		WithTag("account", "1234"),
+		WithTag(TemplateTag, "overridden"),
+		WithAttachment("terms.txt", []byte("terms")),
+		WithSendAt(sendAt),
+		WithPriority(PriorityHigh),
+		WithSubject("Meow Inc. newsletter"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Meow Inc. newsletter", email.Subject)
+	assert.Equal(t, "spring", email.Headers["X-Campaign"])
+	assert.Equal(t, "1 (Highest)", email.Headers["X-Priority"])
+	assert.Equal(t, "high", email.Headers["Importance"])
+	assert.Contains(t, email.Headers[ListUnsubscribeHeader], "https://console.example.com/unsubscribe?token=", "headers set by build take precedence")
+
+	assert.Equal(t, []newman.Tag{
+		{Name: TemplateTag, Value: "subscribe"},
+		{Name: CategoryTag, Value: "marketing"},
+		{Name: SendAtTag, Value: "2026-03-14T21:00:00Z"},
+		{Name: "account", Value: "1234"},
+		{Name: "tenant", Value: "meow"},
+	}, email.Tags)
+
+	require.Len(t, email.Attachments, 1)
+	assert.Equal(t, "terms.txt", email.Attachments[0].Filename)
+}
+
+func TestMessageOptionsWithBuild(t *testing.T) {
+	data := EmailData{
+		Config:    Config{FromEmail: "no-reply@example.com"},
+		Recipient: Recipient{Email: "test@example.com"},
+		Template:  "custom",
+	}
+
+	data.Apply(WithSubject("Custom"), WithTag("tenant", "meow"), WithPriority(PriorityLow), WithCC("cc@example.com"))
+
+	email, err := data.Build("text", "<p>html</p>")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Custom", email.Subject)
+	assert.Equal(t, []string{"cc@example.com"}, email.Cc)
+	assert.Equal(t, "5 (Lowest)", email.Headers["X-Priority"])
+	assert.Contains(t, email.Tags, newman.Tag{Name: "tenant", Value: "meow"})
+	assert.Contains(t, email.Tags, newman.Tag{Name: TemplateTag, Value: "custom"})
+}
@@ -1,15 +1,18 @@
 package emailtemplates
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/mail"
 	"os"
 	"path/filepath"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/theopenlane/newman"
 )
 
 var (
@@ -44,6 +47,125 @@ func (c *Config) Validate() error {
 // Option is a function that sets a field on an EmailMessage
 type Option func(*Config)
 
+// EmailOption is a function that sets a field on the data of a single email
+type EmailOption func(*EmailData)
+
+// Apply the email options to the data, for emails built from custom templates with Build
+func (e *EmailData) Apply(opts ...EmailOption) {
+	for _, opt := range opts {
+		opt(e)
+	}
+}
+
+// context returns the context set with WithContext, or the background context
+func (e EmailData) context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+
+	return e.ctx
+}
+
+// WithCC copies the addresses on the email
+func WithCC(addresses ...string) EmailOption {
+	return func(e *EmailData) {
+		e.CC = append(e.CC, addresses...)
+	}
+}
+
+// WithBCC blind copies the addresses on the email
+func WithBCC(addresses ...string) EmailOption {
+	return func(e *EmailData) {
+		e.BCC = append(e.BCC, addresses...)
+	}
+}
+
+// WithReplyTo sends replies to the email to the addresses
+func WithReplyTo(addresses ...string) EmailOption {
+	return func(e *EmailData) {
+		e.ReplyTo = append(e.ReplyTo, addresses...)
+	}
+}
+
+// WithHeader adds the header to the message
+func WithHeader(key, value string) EmailOption {
+	return func(e *EmailData) {
+		if e.Headers == nil {
+			e.Headers = map[string]string{}
+		}
+
+		e.Headers[key] = value
+	}
+}
+
+// WithTag adds the tag to the message, the template, category, message_id and send_at tags are set
+// by the builders and cannot be replaced
+func WithTag(name, value string) EmailOption {
+	return func(e *EmailData) {
+		if e.Tags == nil {
+			e.Tags = map[string]string{}
+		}
+
+		e.Tags[name] = value
+	}
+}
+
+// WithAttachment attaches the content to the message as the named file
+func WithAttachment(fileName string, content []byte) EmailOption {
+	return func(e *EmailData) {
+		e.Attachments = append(e.Attachments, newman.NewAttachment(fileName, content))
+	}
+}
+
+// WithSendAt schedules the email to be sent at the time, for senders that support scheduling
+func WithSendAt(sendAt time.Time) EmailOption {
+	return func(e *EmailData) {
+		e.SendAt = sendAt
+	}
+}
+
+// WithPriority sets the priority of the email
+func WithPriority(priority Priority) EmailOption {
+	return func(e *EmailData) {
+		e.Priority = priority
+	}
+}
+
+// WithSubject replaces the subject of the template
+func WithSubject(subject string) EmailOption {
+	return func(e *EmailData) {
+		e.subject = subject
+	}
+}
+
+// WithContext sets the context passed to the services called while building the email, e.g. the Shortener
+func WithContext(ctx context.Context) EmailOption {
+	return func(e *EmailData) {
+		e.ctx = ctx
+	}
+}
+
+// WithMessageID sets the ID of the email used to correlate tracking events, one is generated if it is not set
+func WithMessageID(id string) EmailOption {
+	return func(e *EmailData) {
+		e.MessageID = id
+	}
+}
+
+// WithLinkExpiry sets when the action link in the email stops working, so the email can show it
+func WithLinkExpiry(expiresAt time.Time) EmailOption {
+	return func(e *EmailData) {
+		e.setLinkExpiry(expiresAt, time.Until(expiresAt).Round(time.Minute))
+	}
+}
+
+// WithLinkTTL sets how long the action link in the email is valid for, so the email can show it
+func WithLinkTTL(ttl time.Duration) EmailOption {
+	return func(e *EmailData) {
+		e.setLinkExpiry(time.Now().Add(ttl), ttl)
+	}
+}
+
 // WithCompanyName sets the company name for the email
 func WithCompanyName(name string) Option {
 	return func(t *Config) {
@@ -135,6 +257,149 @@ func WithVerifyBillingURL(url string) Option {
 	}
 }
 
+// WithTermsURL sets the terms of service URL linked in the footer
+func WithTermsURL(url string) Option {
+	return func(t *Config) {
+		t.URLS.TermsOfService = url
+	}
+}
+
+// WithPrivacyURL sets the privacy policy URL linked in the footer
+func WithPrivacyURL(url string) Option {
+	return func(t *Config) {
+		t.URLS.Privacy = url
+	}
+}
+
+// WithLinkValidation validates the links in every email against the allowed domains, in strict
+// mode building an email with an invalid link fails instead of logging a warning
+func WithLinkValidation(strict bool, allowedDomains ...string) Option {
+	return func(c *Config) {
+		c.LinkValidation = LinkValidation{
+			Enabled:        true,
+			Strict:         strict,
+			AllowedDomains: allowedDomains,
+		}
+	}
+}
+
+// WithSignedURLs signs the action URLs with the key, valid for the ttl, in addition to the token.
+// Use WithSigningKey to keep accepting links signed with a previous key after rotating
+func WithSignedURLs(keyID, secret string, ttl time.Duration) Option {
+	return func(c *Config) {
+		c.SignedURLs.Enabled = true
+		c.SignedURLs.KeyID = keyID
+		c.SignedURLs.TTL = ttl
+
+		WithSigningKey(keyID, secret)(c)
+	}
+}
+
+// WithSigningKey adds a key that signed URLs are verified with, without making it the active key
+func WithSigningKey(keyID, secret string) Option {
+	return func(c *Config) {
+		if c.SignedURLs.Keys == nil {
+			c.SignedURLs.Keys = map[string]string{}
+		}
+
+		c.SignedURLs.Keys[keyID] = secret
+	}
+}
+
+// WithShortener shortens the action links with the shortener, using the short link as the href, the
+// displayed text or both depending on the mode
+func WithShortener(s Shortener, mode ShortLinkMode) Option {
+	return func(c *Config) {
+		c.Shortener = s
+		c.ShortLinkMode = mode
+	}
+}
+
+// WithUTM adds the campaign parameters to the links of the email rendered from the template, e.g. welcome
+func WithUTM(template string, params UTMParams) Option {
+	return func(c *Config) {
+		if c.UTM.Templates == nil {
+			c.UTM.Templates = map[string]UTMParams{}
+		}
+
+		c.UTM.Templates[template] = params
+	}
+}
+
+// WithClickTracking rewrites the links of the emails to redirect through the click URL signed with the
+// secret, except in the excluded templates; serve a ClickHandler on the click URL
+func WithClickTracking(clickURL, secret string, excludeTemplates ...string) Option {
+	return func(c *Config) {
+		c.Tracking.Clicks = true
+		c.Tracking.ClickURL = clickURL
+		c.Tracking.Secret = secret
+		c.Tracking.ExcludeTemplates = append(c.Tracking.ExcludeTemplates, excludeTemplates...)
+	}
+}
+
+// WithOpenTracking adds a pixel served from the open URL signed with the secret to the emails, except
+// in the excluded templates; serve an OpenHandler on the open URL
+func WithOpenTracking(openURL, secret string, excludeTemplates ...string) Option {
+	return func(c *Config) {
+		c.Tracking.Opens = true
+		c.Tracking.OpenURL = openURL
+		c.Tracking.Secret = secret
+		c.Tracking.ExcludeTemplates = append(c.Tracking.ExcludeTemplates, excludeTemplates...)
+	}
+}
+
+// WithTemplateRecipients sets the addresses copied on or replied to by every email of the template,
+// e.g. to blind copy an archive on the trust center NDA emails
+func WithTemplateRecipients(template string, defaults RecipientDefaults) Option {
+	return func(c *Config) {
+		if c.TemplateRecipients == nil {
+			c.TemplateRecipients = map[string]RecipientDefaults{}
+		}
+
+		c.TemplateRecipients[template] = defaults
+	}
+}
+
+// WithDisplayNames adds the display names of the sender and recipient to the from and to addresses,
+// the from name defaults to the company name when empty
+func WithDisplayNames(fromName string) Option {
+	return func(c *Config) {
+		c.DisplayNames = true
+		c.FromName = fromName
+	}
+}
+
+// WithSenderName sets the display name of the sender for the template, rendered with the data of the email
+func WithSenderName(template, name string) Option {
+	return func(c *Config) {
+		if c.SenderNames == nil {
+			c.SenderNames = map[string]string{}
+		}
+
+		c.SenderNames[template] = name
+	}
+}
+
+// WithSandbox redirects the emails to the catch-all address, keeping recipients on the allowed domains,
+// and marks them with the environment name in the subject and banner
+func WithSandbox(environment, redirect string, allowedDomains ...string) Option {
+	return func(c *Config) {
+		c.Sandbox = SandboxConfig{
+			Enabled:        true,
+			Environment:    environment,
+			Redirect:       redirect,
+			AllowedDomains: allowedDomains,
+		}
+	}
+}
+
+// WithSuppressions sets the suppression list checked before emails are built
+func WithSuppressions(store SuppressionStore) Option {
+	return func(c *Config) {
+		c.Suppressions = store
+	}
+}
+
 // WithLogoURL sets the logo URL for the email, this field is optional and
 // omitted from the email if not provided
 func WithLogoURL(url string) Option {
@@ -151,11 +416,17 @@ func WithTemplatesPath(p string) Option {
 	}
 }
 
-func (c *Config) ensureDefaults() error {
+// ensureDefaults loads the custom templates and sets the defaults before an email of the template is built,
+// returning ValidationErrors when the URLs the template links to are not set
+func (c *Config) ensureDefaults(template string) error {
 	if err := ensureCustomTemplatesLoaded(c.TemplatesPath); err != nil {
 		return err
 	}
 
+	if err := c.requireURLs(templateURLs[template]...); err != nil {
+		return err
+	}
+
 	c.ensureCopyrightDate()
 
 	return nil
@@ -165,19 +436,22 @@ func (c *Config) ensureDefaults() error {
 // Also this makes sure if we have a custom template path, we should load them
 // this will include the partials directory as well if it exists
 func ensureCustomTemplatesLoaded(templatePath string) (err error) {
+	// configs using the default templates do not use up the load for a later custom path
+	if templatePath == defaultTemplatesDir || templatePath == "" {
+		return nil
+	}
+
 	templateLoadOnce.Do(func() {
-		if templatePath != defaultTemplatesDir && templatePath != "" {
-			partials, err = getPartials(templatePath)
-			if err != nil {
-				log.Fatal().Err(err).Msgf("could not load partials from %q, skipping", templatePath)
-				return
-			}
+		partials, err = getPartials(templates, templatePath)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("could not load partials from %q, skipping", templatePath)
+			return
+		}
 
-			err = loadTemplatesFromDir(templatePath, partials)
-			if err != nil {
-				log.Error().Err(err).Msgf("could not load templates from %q", templatePath)
-				return
-			}
+		err = loadTemplatesFromDir(templates, templatePath, partials)
+		if err != nil {
+			log.Error().Err(err).Msgf("could not load templates from %q", templatePath)
+			return
 		}
 	})
 
@@ -192,8 +466,8 @@ func (c *Config) ensureCopyrightDate() {
 	}
 }
 
-// getPartials loads partials from the specified directory
-func getPartials(path string) ([]string, error) {
+// getPartials loads partials from the specified directory into dst
+func getPartials(dst map[string]*template.Template, path string) ([]string, error) {
 	partials := []string{}
 
 	templateFiles, err := os.ReadDir(path)
@@ -204,7 +478,7 @@ func getPartials(path string) ([]string, error) {
 	for _, file := range templateFiles {
 		if file.Name() == defaultPartialsDir {
 			partialPath := filepath.Join(path, file.Name())
-			if err := loadTemplatesFromDir(partialPath, partials); err != nil {
+			if err := loadTemplatesFromDir(dst, partialPath, partials); err != nil {
 				return nil, err
 			}
 
@@ -226,9 +500,9 @@ func getPartials(path string) ([]string, error) {
 	return partials, nil
 }
 
-// loadTemplatesFromDir loads templates from the specified directory
+// loadTemplatesFromDir loads templates from the specified directory into dst
 // and recursively loads partials
-func loadTemplatesFromDir(path string, partials []string) error {
+func loadTemplatesFromDir(dst map[string]*template.Template, path string, partials []string) error {
 	templateFiles, err := os.ReadDir(path)
 	if err != nil {
 		return fmt.Errorf("could not read template files from %q: %w", path, err)
@@ -241,7 +515,7 @@ func loadTemplatesFromDir(path string, partials []string) error {
 
 		key := file.Name()
 
-		templates[key], err = parseCustomTemplate(file, path, partials)
+		dst[key], err = parseCustomTemplate(file, path, partials)
 		if err != nil {
 			return err
 		}
@@ -250,29 +524,34 @@ func loadTemplatesFromDir(path string, partials []string) error {
 	return nil
 }
 
-// validate checks if all required fields are set and valid
+// validate checks every field of the config, returning ValidationErrors with all the problems found
 func (c *Config) validate() error {
+	v := validator{}
+
 	if c.TemplatesPath != "" {
 		if err := ensureCustomTemplatesLoaded(c.TemplatesPath); err != nil {
-			return err
+			v.add("TemplatesPath", err)
 		}
 	}
 
-	if c.CompanyAddress == "" {
-		return newMissingRequiredFieldError("company address")
-	}
-
-	if c.CompanyName == "" {
-		return newMissingRequiredFieldError("company name")
-	}
-
-	if c.FromEmail == "" {
-		return newMissingRequiredFieldError("sender email")
-	}
+	v.required("CompanyAddress", "company address", c.CompanyAddress)
+	v.required("CompanyName", "company name", c.CompanyName)
 
-	if _, err := mail.ParseAddress(c.FromEmail); err != nil {
-		return ErrInvalidSenderEmail
+	if v.required("FromEmail", "sender email", c.FromEmail) {
+		if _, err := mail.ParseAddress(c.FromEmail); err != nil {
+			v.add("FromEmail", ErrInvalidSenderEmail)
+		}
 	}
 
-	return nil
+	v.email("SupportEmail", c.SupportEmail)
+	v.email("QuestionnaireEmail", c.QuestionnaireEmail)
+	v.validateLogo("LogoURL", c.LogoURL)
+	v.validateURLs("URLS", c.URLS)
+	v.validateSenders(c)
+	v.validateRecipients("TemplateRecipients", c.TemplateRecipients)
+	v.nested("SignedURLs", c.SignedURLs.validate())
+	v.nested("Tracking", c.Tracking.validate())
+	v.nested("Sandbox", c.Sandbox.validate())
+
+	return v.err()
 }
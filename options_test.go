@@ -128,7 +128,7 @@ func TestNew(t *testing.T) {
 				WithFromEmail("test@example.com"),
 			},
 			wantErr: true,
-			errMsg:  "company address is required",
+			errMsg:  "invalid config: CompanyAddress: company address is required",
 		},
 		{
 			name: "missing company name",
@@ -138,7 +138,7 @@ func TestNew(t *testing.T) {
 				WithFromEmail("test@example.com"),
 			},
 			wantErr: true,
-			errMsg:  "company name is required",
+			errMsg:  "invalid config: CompanyName: company name is required",
 		},
 		{
 			name: "missing from email",
@@ -148,7 +148,7 @@ func TestNew(t *testing.T) {
 				WithCompanyName("Test Company"),
 			},
 			wantErr: true,
-			errMsg:  "sender email is required",
+			errMsg:  "invalid config: FromEmail: sender email is required",
 		},
 		{
 			name: "invalid from email",
@@ -159,7 +159,7 @@ func TestNew(t *testing.T) {
 				WithFromEmail("invalid-email"),
 			},
 			wantErr: true,
-			errMsg:  "please provide a valid sender email ( from email )",
+			errMsg:  "invalid config: FromEmail: please provide a valid sender email ( from email )",
 		},
 		{
 			name: "valid configuration",
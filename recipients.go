@@ -0,0 +1,88 @@
+package emailtemplates
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// replyToHeader is set instead of the reply to of the message when an email has more than one reply to
+// address, as the message holds one and setting both would send two Reply-To headers
+const replyToHeader = "Reply-To"
+
+// RecipientDefaults are the addresses copied on, or replied to, every email of a template
+type RecipientDefaults struct {
+	// CC are the addresses copied on the email
+	CC []string `koanf:"cc" json:"cc"`
+	// BCC are the addresses blind copied on the email, e.g. a compliance archive
+	BCC []string `koanf:"bcc" json:"bcc"`
+	// ReplyTo are the addresses replies are sent to instead of the sender
+	ReplyTo []string `koanf:"replyto" json:"replyto"`
+}
+
+// recipients are the validated addresses of an email other than the recipient
+type recipients struct {
+	cc      []string
+	bcc     []string
+	replyTo []string
+}
+
+// recipients returns the defaults of the template followed by the addresses set on the email,
+// validated with net/mail and without duplicates. The reply to addresses of the sender profile are used
+// when the template and email set none
+func (e EmailData) recipients() (recipients, error) {
+	defaults := e.TemplateRecipients[e.Template]
+
+	var (
+		r   recipients
+		err error
+	)
+
+	if r.cc, err = parseAddresses("cc", defaults.CC, e.CC); err != nil {
+		return r, err
+	}
+
+	if r.bcc, err = parseAddresses("bcc", defaults.BCC, e.BCC); err != nil {
+		return r, err
+	}
+
+	if r.replyTo, err = parseAddresses("reply to", defaults.ReplyTo, e.ReplyTo); err != nil {
+		return r, err
+	}
+
+	if len(r.replyTo) == 0 {
+		if r.replyTo, err = parseAddresses("reply to", e.senderProfile().ReplyTo); err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+// parseAddresses returns the bare addresses of the lists, duplicates are removed ignoring case
+func parseAddresses(field string, lists ...[]string) ([]string, error) {
+	var (
+		addresses []string
+		seen      = map[string]bool{}
+	)
+
+	for _, list := range lists {
+		for _, raw := range list {
+			addr, err := mail.ParseAddress(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s %q: %w", ErrInvalidAddress, field, raw, err)
+			}
+
+			key := strings.ToLower(addr.Address)
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			addresses = append(addresses, addr.Address)
+		}
+	}
+
+	return addresses, nil
+}
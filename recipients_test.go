@@ -0,0 +1,52 @@
+package emailtemplates
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddresses(t *testing.T) {
+	addresses, err := parseAddresses("cc", []string{"Billing <billing@example.com>", "archive@example.com"}, []string{"BILLING@example.com", "other@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing@example.com", "archive@example.com", "other@example.com"}, addresses)
+
+	_, err = parseAddresses("bcc", []string{"not an address"})
+	require.ErrorIs(t, err, ErrInvalidAddress)
+	assert.Contains(t, err.Error(), "bcc")
+}
+
+func TestRecipientsInEmail(t *testing.T) {
+	cfg := categoryConfig()
+	WithTemplateRecipients("billing_email_changed", RecipientDefaults{BCC: []string{"archive@example.com"}})(&cfg)
+
+	email, err := cfg.NewBillingEmailChangedEmail(Recipient{Email: "new@example.com"}, BillingEmailChangedTemplateData{
+		OrganizationName: "Meow Inc.",
+		OldEmail:         "old@example.com",
+		NewEmail:         "new@example.com",
+	}, WithCC("Old Contact <old@example.com>"), WithBCC("archive@example.com", "audit@example.com"), WithReplyTo("support@example.com", "billing@example.com"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"new@example.com"}, email.To)
+	assert.Equal(t, []string{"old@example.com"}, email.Cc)
+	assert.Equal(t, []string{"archive@example.com", "audit@example.com"}, email.Bcc)
+	assert.Empty(t, email.ReplyTo, "several reply to addresses are only set in the header")
+	assert.Equal(t, "support@example.com, billing@example.com", email.Headers["Reply-To"])
+
+	// template defaults apply to every email of the template
+	WithTemplateRecipients("trust_center_nda_signed", RecipientDefaults{BCC: []string{"nda-archive@example.com"}})(&cfg)
+
+	email, err = cfg.NewTrustCenterNDASignedEmail(Recipient{Email: "test@example.com"}, TrustCenterNDASignedData{
+		OrganizationName: "Meow Inc.",
+		TrustCenterURL:   "https://trust.example.com",
+	}, bytes.NewReader([]byte("nda")), "nda.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nda-archive@example.com"}, email.Bcc)
+	assert.Empty(t, email.Cc)
+	assert.Empty(t, email.ReplyTo)
+
+	_, err = cfg.NewWelcomeEmail(Recipient{Email: "test@example.com"}, WithCC("invalid"))
+	require.ErrorIs(t, err, ErrInvalidAddress)
+}
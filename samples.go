@@ -0,0 +1,472 @@
+package emailtemplates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSamplesDir is the directory next to the templates that custom samples are loaded from
+	defaultSamplesDir = "samples"
+
+	// DefaultSample is the name of the canonical sample of every template
+	DefaultSample = "default"
+)
+
+// Sample is a named set of data used to render a template in previews, tests and catalogs
+type Sample struct {
+	// Name identifies the sample for the template, e.g. default or non-ascii
+	Name string `json:"name"`
+	// Description explains what the sample is exercising
+	Description string `json:"description,omitempty"`
+	// Data is the template data, the built in emails use their typed data while samples loaded
+	// from json are maps keyed by the template field names, e.g. InviterName
+	Data interface{} `json:"data"`
+}
+
+var (
+	// builtinSamplesOnce prepares the built in samples on first use rather than when the package is imported
+	builtinSamplesOnce sync.Once
+	builtinSampleMap   map[string][]Sample
+	builtinSamplesErr  error
+
+	samplesMu sync.RWMutex
+
+	// registeredSamples are the samples registered with RegisterSamples
+	registeredSamples = map[string][]Sample{}
+
+	// loadedSamples are the samples loaded with LoadSamples, replaced on every load so samples removed
+	// from the samples directory do not linger
+	loadedSamples = map[string][]Sample{}
+
+	// sampleConfig is the config used by every built in sample
+	sampleConfig = Config{
+		CompanyName:    "Openlane",
+		CompanyAddress: "5150 Broadway St &middot; San Antonio, TX 78209",
+		Corporation:    "theopenlane, Inc.",
+		Year:           time.Now().Year(),
+		FromEmail:      "no-reply@mail.theopenlane.io",
+		SupportEmail:   "support@theopenlane.io",
+		LogoURL:        "https://www.theopenlane.io/logo.png",
+		URLS: URLConfig{
+			Root:             "https://www.theopenlane.io",
+			Product:          "https://console.theopenlane.io",
+			Docs:             "https://docs.theopenlane.io",
+			Verify:           "https://console.theopenlane.io/verify?token=sample-token",
+			Invite:           "https://console.theopenlane.io/invite?token=sample-token",
+			PasswordReset:    "https://console.theopenlane.io/password-reset?token=sample-token",
+			VerifySubscriber: "https://console.theopenlane.io/subscriber-verify?token=sample-token",
+			VerifyBilling:    "https://console.theopenlane.io/verify-billing?token=sample-token",
+			Questionnaire:    "https://console.theopenlane.io/questionnaire?token=sample-token",
+			Unsubscribe:      "https://console.theopenlane.io/unsubscribe",
+		},
+		SignedURLs: SignedURLConfig{
+			KeyID: "sample",
+			Keys:  map[string]string{"sample": "sample-secret"},
+		},
+	}
+
+	// sampleTime is used for every timestamp in the samples so renders are stable
+	sampleTime = time.Date(2026, time.March, 14, 15, 9, 26, 0, time.UTC)
+
+	// sampleLinkTTL and sampleResetTTL are how long the action links in the samples are valid for
+	sampleLinkTTL  = 24 * time.Hour
+	sampleResetTTL = 15 * time.Minute
+)
+
+// sampleProfile includes the values that differ between the variants of the built in samples
+type sampleProfile struct {
+	name         string
+	description  string
+	recipient    Recipient
+	inviter      string
+	organization string
+	assessment   string
+}
+
+// sampleProfiles are rendered for every built in template, including edge cases for copy and layout
+var sampleProfiles = []sampleProfile{
+	{
+		name:         DefaultSample,
+		description:  "realistic data for the common case",
+		recipient:    Recipient{Email: "mitb@theopenlane.io", FirstName: "Matt", LastName: "Anderson"},
+		inviter:      "Sarah Funk",
+		organization: "Meow Meow Inc.",
+		assessment:   "Vendor Security Assessment 2026",
+	},
+	{
+		name:        "long-names",
+		description: "very long names to check wrapping and truncation",
+		recipient: Recipient{
+			Email:     "bartholomew.maximilian.featherstonehaugh-worthington@subsidiary.international-holdings.example.com",
+			FirstName: "Bartholomew Maximilian Alexander",
+			LastName:  "Featherstonehaugh-Worthington-Smythe",
+		},
+		inviter:      "Christopher Jonathan Montgomery-Richardson the Third",
+		organization: "The Extraordinarily Long and Comprehensively Named International Holdings Corporation of America",
+		assessment:   "Annual Third Party Information Security, Privacy, and Business Continuity Risk Assessment Questionnaire",
+	},
+	{
+		name:         "non-ascii",
+		description:  "accented, non-latin and html sensitive characters",
+		recipient:    Recipient{Email: "zoe@exämple.com", FirstName: "Zoë", LastName: "Łukasiewicz-Ångström"},
+		inviter:      "José Müller & 田中 太郎",
+		organization: "Société Générale <Ünïcødé> 株式会社",
+		assessment:   "Évaluation de sécurité – 2026 “Q1”",
+	},
+}
+
+// builtinSamples returns the samples for every built in template
+func builtinSamples() (map[string][]Sample, error) {
+	out := map[string][]Sample{}
+
+	var err error
+
+	// prepare returns the email data prepared for the template, keeping the first error
+	prepare := func(e EmailData, template string) EmailData {
+		prepared, prepareErr := sampleEmail(e, template)
+		if err == nil {
+			err = prepareErr
+		}
+
+		return prepared
+	}
+
+	for _, p := range sampleProfiles {
+		add := func(name string, data interface{}) {
+			out[name] = append(out[name], Sample{Name: p.name, Description: p.description, Data: data})
+		}
+
+		email := EmailData{
+			Config:    sampleConfig,
+			Recipient: p.recipient,
+		}
+
+		// emails with an action link show when it expires
+		expiring := email
+		expiring.setLinkExpiry(sampleTime.Add(sampleLinkTTL), sampleLinkTTL)
+
+		reset := email
+		reset.setLinkExpiry(sampleTime.Add(sampleResetTTL), sampleResetTTL)
+
+		invite := InviteData{
+			EmailData:        prepare(expiring, "invite"),
+			InviterName:      p.inviter,
+			OrganizationName: p.organization,
+			Role:             "admin",
+		}
+
+		add("welcome", WelcomeData{EmailData: prepare(email, "welcome")})
+		add("verify_email", VerifyEmailData{EmailData: prepare(expiring, "verify_email")})
+		add("invite", invite)
+		add("invite_joined", InviteData{
+			EmailData:        prepare(email, "invite_joined"),
+			InviterName:      p.inviter,
+			OrganizationName: p.organization,
+			Role:             "admin",
+		})
+		add("password_reset_request", ResetRequestData{EmailData: prepare(reset, "password_reset_request")})
+		add("password_reset_success", ResetSuccessData{EmailData: prepare(email, "password_reset_success")})
+		add("subscribe", SubscriberEmailData{EmailData: prepare(email, "subscribe"), OrganizationName: p.organization})
+		add("verify_billing", VerifyBillingEmailData{EmailData: prepare(email, "verify_billing"), OrganizationName: p.organization})
+		add("trust_center_nda_request", TrustCenterNDARequestEmailData{
+			EmailData:         prepare(email, "trust_center_nda_request"),
+			OrganizationName:  p.organization,
+			TrustCenterNDAURL: "https://trust.meowmeow.com/nda?token=sample-token",
+		})
+		add("trust_center_nda_signed", TrustCenterNDASignedEmailData{
+			EmailData:        prepare(email, "trust_center_nda_signed"),
+			OrganizationName: p.organization,
+			TrustCenterURL:   "https://trust.meowmeow.com",
+		})
+		add("trust_center_auth", TrustCenterAuthEmailData{
+			EmailData:          prepare(expiring, "trust_center_auth"),
+			OrganizationName:   p.organization,
+			TrustCenterAuthURL: "https://trust.meowmeow.com/auth?token=sample-token",
+		})
+		add("questionnaire_auth", QuestionnaireAuthEmailData{
+			EmailData:            prepare(expiring, "questionnaire_auth"),
+			CompanyName:          p.organization,
+			AssessmentName:       p.assessment,
+			QuestionnaireAuthURL: "https://console.theopenlane.io/questionnaire?token=sample-token",
+		})
+		add("billing_email_changed", BillingEmailChangedData{
+			EmailData:        prepare(email, "billing_email_changed"),
+			OrganizationName: p.organization,
+			OldEmail:         "billing@meowmeow.com",
+			NewEmail:         p.recipient.Email,
+			ChangedAt:        sampleTime,
+		})
+	}
+
+	return out, err
+}
+
+// builtins returns the built in samples, prepared once on first use
+func builtins() (map[string][]Sample, error) {
+	builtinSamplesOnce.Do(func() {
+		builtinSampleMap, builtinSamplesErr = builtinSamples()
+		if builtinSamplesErr != nil {
+			builtinSamplesErr = fmt.Errorf("could not prepare the built in samples: %w", builtinSamplesErr)
+		}
+	})
+
+	return builtinSampleMap, builtinSamplesErr
+}
+
+// sampleEmail returns the email data prepared for the template the way it is when the email is built,
+// so the samples show the footer and unsubscribe link of its category
+func sampleEmail(e EmailData, template string) (EmailData, error) {
+	e.Template = template
+	e.Category = e.CategoryOf(template)
+
+	if err := e.prepareUnsubscribe(); err != nil {
+		return e, fmt.Errorf("could not prepare the unsubscribe link of the %q sample: %w", template, err)
+	}
+
+	return e, nil
+}
+
+// RegisterSamples registers samples for a custom template, replacing any samples
+// previously registered with the same name for that template
+func RegisterSamples(name string, s ...Sample) {
+	samplesMu.Lock()
+	defer samplesMu.Unlock()
+
+	registeredSamples[name] = mergeSamples(registeredSamples[name], s)
+}
+
+// mergeSamples returns the existing samples with the samples of the same name replaced and the others appended
+func mergeSamples(existing, s []Sample) []Sample {
+	merged := append([]Sample{}, existing...)
+
+	for _, sample := range s {
+		replaced := false
+
+		for i := range merged {
+			if merged[i].Name == sample.Name {
+				merged[i] = sample
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			merged = append(merged, sample)
+		}
+	}
+
+	return merged
+}
+
+// Samples returns the samples for the named template, built in templates list the default sample first.
+// Templates without registered samples get the samples of the common email data so every
+// template can be rendered. An error is returned when the built in samples cannot be prepared
+func Samples(name string) ([]Sample, error) {
+	builtin, err := builtins()
+	if err != nil {
+		return nil, err
+	}
+
+	samplesMu.RLock()
+	defer samplesMu.RUnlock()
+
+	b, isBuiltin := builtin[name]
+	registered, isRegistered := registeredSamples[name]
+	loaded, isLoaded := loadedSamples[name]
+
+	if isBuiltin || isRegistered || isLoaded {
+		return mergeSamples(mergeSamples(b, registered), loaded), nil
+	}
+
+	out := []Sample{}
+
+	for _, p := range sampleProfiles {
+		out = append(out, Sample{
+			Name:        p.name,
+			Description: p.description,
+			Data:        EmailData{Config: sampleConfig, Recipient: p.recipient},
+		})
+	}
+
+	return out, nil
+}
+
+// SampleData returns the data of the named sample for a template, an empty sample name returns
+// the default sample. ErrSampleNotFound is returned if the sample does not exist
+func SampleData(name, sample string) (interface{}, error) {
+	if sample == "" {
+		sample = DefaultSample
+	}
+
+	samples, err := Samples(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range samples {
+		if s.Name == sample {
+			return s.Data, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q for %s", ErrSampleNotFound, sample, name)
+}
+
+// SampleTemplates returns the sorted names of all templates with built in, registered or loaded samples
+func SampleTemplates() ([]string, error) {
+	builtin, err := builtins()
+	if err != nil {
+		return nil, err
+	}
+
+	samplesMu.RLock()
+	defer samplesMu.RUnlock()
+
+	seen := map[string]bool{}
+	names := []string{}
+
+	for _, m := range []map[string][]Sample{builtin, registeredSamples, loadedSamples} {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// LoadSamples registers the samples found in the samples directory of a custom templates path,
+// e.g. samples/welcome.json for welcome.html. A file either contains a single json object which is
+// used as the default sample data, or a list of samples with a name, description and data.
+// The sample data is merged over the default sample config, so only the template specific
+// fields need to be provided. Each load replaces the samples of the previous load
+func LoadSamples(path string) error {
+	dir := filepath.Join(path, defaultSamplesDir)
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	loaded := map[string][]Sample{}
+
+	for _, match := range matches {
+		name := strings.TrimSuffix(filepath.Base(match), ".json")
+
+		s, err := readSamples(match)
+		if err != nil {
+			return fmt.Errorf("could not load samples for %q: %w", name, err)
+		}
+
+		loaded[name] = mergeSamples(loaded[name], s)
+	}
+
+	samplesMu.Lock()
+	defer samplesMu.Unlock()
+
+	loadedSamples = loaded
+
+	return nil
+}
+
+// readSamples reads the samples from a json file and merges them over the sample config
+func readSamples(file string) ([]Sample, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []Sample
+
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '{' {
+		var data map[string]interface{}
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+
+		raw = append(raw, Sample{Name: DefaultSample, Data: data})
+	} else if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	out := make([]Sample, 0, len(raw))
+
+	for _, r := range raw {
+		if r.Name == "" {
+			return nil, newMissingRequiredFieldError("sample name")
+		}
+
+		data := templateMap(EmailData{Config: sampleConfig, Recipient: sampleProfiles[0].recipient})
+
+		if custom, ok := r.Data.(map[string]interface{}); ok {
+			mergeMaps(data, custom)
+		}
+
+		out = append(out, Sample{Name: r.Name, Description: r.Description, Data: data})
+	}
+
+	return out, nil
+}
+
+// templateMap converts a struct into a map keyed by the field names used in templates,
+// fields of embedded structs are promoted the same way the template engine resolves them
+func templateMap(v interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+
+	val := reflect.Indirect(reflect.ValueOf(v))
+	if val.Kind() != reflect.Struct {
+		return out
+	}
+
+	for i := range val.NumField() {
+		field := val.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		value := val.Field(i)
+
+		switch {
+		case field.Anonymous && value.Kind() == reflect.Struct:
+			for k, v := range templateMap(value.Interface()) {
+				if _, ok := out[k]; !ok {
+					out[k] = v
+				}
+			}
+		case value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Time{}):
+			out[field.Name] = templateMap(value.Interface())
+		default:
+			out[field.Name] = value.Interface()
+		}
+	}
+
+	return out
+}
+
+// mergeMaps recursively merges src into dst, nested maps are merged rather than replaced
+func mergeMaps(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcOK := v.(map[string]interface{})
+		dstMap, dstOK := dst[k].(map[string]interface{})
+
+		if srcOK && dstOK {
+			mergeMaps(dstMap, srcMap)
+			continue
+		}
+
+		dst[k] = v
+	}
+}
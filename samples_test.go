@@ -0,0 +1,178 @@
+package emailtemplates
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinSamples(t *testing.T) {
+	set, err := LoadTemplateSet("")
+	require.NoError(t, err)
+
+	// every built in template ships samples, and every sample renders
+	for _, name := range set.Names() {
+		s, err := Samples(name)
+		require.NoError(t, err)
+		require.NotEmpty(t, s, name)
+		assert.Equal(t, DefaultSample, s[0].Name, name)
+		assert.NotEmpty(t, Subject(name, s[0].Data), name)
+
+		for _, sample := range s {
+			t.Run(name+"/"+sample.Name, func(t *testing.T) {
+				text, html, err := set.Render(name, sample.Data)
+				require.NoError(t, err)
+				assert.NotEmpty(t, text)
+				assert.NotEmpty(t, html)
+			})
+		}
+	}
+}
+
+func TestBuiltinSamplesError(t *testing.T) {
+	original := sampleConfig
+
+	reset := func() {
+		builtinSamplesOnce = sync.Once{}
+		builtinSampleMap, builtinSamplesErr = nil, nil
+	}
+
+	t.Cleanup(func() {
+		sampleConfig = original
+
+		reset()
+	})
+
+	// an active key id without its key fails the unsubscribe links of the samples
+	reset()
+
+	sampleConfig.SignedURLs = SignedURLConfig{KeyID: "missing"}
+
+	_, err := Samples("subscribe")
+	require.ErrorIs(t, err, ErrSigningKeyNotFound)
+
+	_, err = SampleData("subscribe", DefaultSample)
+	require.ErrorIs(t, err, ErrSigningKeyNotFound)
+
+	_, err = SampleTemplates()
+	require.ErrorIs(t, err, ErrSigningKeyNotFound)
+}
+
+func TestSampleData(t *testing.T) {
+	data, err := SampleData("invite", "")
+	require.NoError(t, err)
+
+	invite, ok := data.(InviteData)
+	require.True(t, ok)
+	assert.Equal(t, "Sarah Funk", invite.InviterName)
+
+	_, err = SampleData("invite", "does-not-exist")
+	require.ErrorIs(t, err, ErrSampleNotFound)
+
+	// templates without samples fall back to the common email data
+	data, err = SampleData("custom_template", "non-ascii")
+	require.NoError(t, err)
+	assert.IsType(t, EmailData{}, data)
+}
+
+func TestLoadSamples(t *testing.T) {
+	require.NoError(t, LoadSamples("testdata"))
+
+	t.Cleanup(func() {
+		samplesMu.Lock()
+		defer samplesMu.Unlock()
+
+		loadedSamples = map[string][]Sample{}
+	})
+
+	data, err := SampleData("invite", DefaultSample)
+	require.NoError(t, err)
+
+	m, ok := data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "Avengers", m["CompanyName"])
+	assert.Equal(t, "Tony Stark", m["InviterName"])
+
+	// nested maps are merged over the sample data
+	recipient, ok := m["Recipient"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "Peter", recipient["FirstName"])
+	assert.Equal(t, "mitb@theopenlane.io", recipient["Email"])
+
+	// the built in non default samples are kept
+	_, err = SampleData("invite", "non-ascii")
+	require.NoError(t, err)
+
+	_, err = SampleData("invite", "guest")
+	require.NoError(t, err)
+
+	data, err = SampleData("welcome", DefaultSample)
+	require.NoError(t, err)
+
+	set, err := LoadTemplateSet("")
+	require.NoError(t, err)
+
+	text, _, err := set.Render("welcome", data)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Avengers")
+}
+
+func TestLoadSamplesReplacesPreviousLoad(t *testing.T) {
+	t.Cleanup(func() {
+		samplesMu.Lock()
+		defer samplesMu.Unlock()
+
+		loadedSamples = map[string][]Sample{}
+	})
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, defaultSamplesDir), 0o750))
+
+	file := filepath.Join(dir, defaultSamplesDir, "custom_reload.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[{"name": "first"}, {"name": "second"}]`), 0o600))
+	require.NoError(t, LoadSamples(dir))
+
+	_, err := SampleData("custom_reload", "second")
+	require.NoError(t, err)
+
+	names, err := SampleTemplates()
+	require.NoError(t, err)
+	assert.Contains(t, names, "custom_reload")
+
+	// samples removed from the file are gone after the reload
+	require.NoError(t, os.WriteFile(file, []byte(`[{"name": "first"}]`), 0o600))
+	require.NoError(t, LoadSamples(dir))
+
+	_, err = SampleData("custom_reload", "second")
+	require.ErrorIs(t, err, ErrSampleNotFound)
+
+	require.NoError(t, os.Remove(file))
+	require.NoError(t, LoadSamples(dir))
+
+	names, err = SampleTemplates()
+	require.NoError(t, err)
+	assert.NotContains(t, names, "custom_reload")
+	assert.Contains(t, names, "invite")
+
+	// the built in samples are untouched
+	_, err = SampleData("invite", DefaultSample)
+	require.NoError(t, err)
+}
+
+func TestTemplateMap(t *testing.T) {
+	m := templateMap(QuestionnaireAuthEmailData{
+		EmailData: EmailData{
+			Config: Config{CompanyName: "Openlane"},
+		},
+		CompanyName: "Meow Meow Inc.",
+	})
+
+	// fields on the outer struct shadow the embedded fields like they do in templates
+	assert.Equal(t, "Meow Meow Inc.", m["CompanyName"])
+	assert.Contains(t, m, "URLS")
+	assert.Contains(t, m, "Recipient")
+}
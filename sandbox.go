@@ -0,0 +1,150 @@
+package emailtemplates
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SandboxOriginalToHeader holds the recipient of an email redirected by the sandbox
+	SandboxOriginalToHeader = "X-Sandbox-Original-To"
+	// SandboxOriginalCCHeader holds the cc addresses of an email redirected by the sandbox
+	SandboxOriginalCCHeader = "X-Sandbox-Original-Cc"
+	// SandboxOriginalBCCHeader holds the bcc addresses of an email redirected by the sandbox, only set when
+	// the redirect address is the only recipient left so the bcc addresses are not disclosed
+	SandboxOriginalBCCHeader = "X-Sandbox-Original-Bcc"
+
+	// defaultSandboxEnvironment is the environment name used when the sandbox does not set one
+	defaultSandboxEnvironment = "sandbox"
+)
+
+// SandboxConfig redirects the emails built in non-production environments, recipients outside the
+// allowed domains are replaced by the redirect address and the originals are kept in headers
+type SandboxConfig struct {
+	// Enabled turns on the sandbox
+	Enabled bool `koanf:"enabled" json:"enabled" default:"false"`
+	// Environment is the name of the environment, prefixed to subjects and shown in the banner, e.g. staging
+	Environment string `koanf:"environment" json:"environment" default:"sandbox"`
+	// Redirect is the catch-all address the recipients outside the allowed domains are replaced by,
+	// without it those recipients are dropped
+	Redirect string `koanf:"redirect" json:"redirect" default:""`
+	// AllowedDomains are the domains, including their subdomains, whose recipients are kept
+	AllowedDomains []string `koanf:"alloweddomains" json:"alloweddomains"`
+}
+
+// Name returns the name of the environment shown in the subject and banner
+func (s SandboxConfig) Name() string {
+	return valueOr(s.Environment, defaultSandboxEnvironment)
+}
+
+// validate ensures an enabled sandbox has a redirect address or allowed domains
+func (s SandboxConfig) validate() error {
+	v := validator{}
+
+	if s.Enabled && len(s.AllowedDomains) == 0 {
+		v.required("Redirect", "sandbox redirect", s.Redirect)
+	}
+
+	v.email("Redirect", s.Redirect)
+
+	return v.err()
+}
+
+// allowed reports whether the address is on an allowed domain or one of its subdomains
+func (s SandboxConfig) allowed(address string) bool {
+	_, domain, _ := strings.Cut(strings.ToLower(address), "@")
+
+	for _, allowed := range s.AllowedDomains {
+		allowed = strings.ToLower(strings.TrimPrefix(allowed, "."))
+
+		if domain == allowed || strings.HasSuffix(domain, "."+allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redirected reports whether the address is outside the allowed domains and replaced by the redirect address,
+// the banner names the original recipient only then
+func (s SandboxConfig) Redirected(address string) bool {
+	return !s.allowed(address)
+}
+
+// rewrite returns the addresses with those outside the allowed domains replaced by the redirect address,
+// addresses already seen are skipped
+func (s SandboxConfig) rewrite(seen map[string]bool, addresses []string) []string {
+	var rewritten []string
+
+	for _, addr := range addresses {
+		if s.Redirected(addr) {
+			addr = s.Redirect
+		}
+
+		if addr == "" || seen[strings.ToLower(addr)] {
+			continue
+		}
+
+		seen[strings.ToLower(addr)] = true
+
+		rewritten = append(rewritten, addr)
+	}
+
+	return rewritten
+}
+
+// sandbox redirects the recipients of the email, returning the to address of the message. The originals
+// are recorded in the headers only when the recipient is redirected
+func (e *EmailData) sandbox(to string, r *recipients, headers map[string]string) (string, error) {
+	seen := map[string]bool{}
+
+	redirected := e.Sandbox.rewrite(seen, []string{e.Recipient.Email})
+	if len(redirected) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrSandboxRecipient, e.Recipient.Email)
+	}
+
+	cc, bcc := r.cc, r.bcc
+
+	r.cc = e.Sandbox.rewrite(seen, r.cc)
+	r.bcc = e.Sandbox.rewrite(seen, r.bcc)
+
+	// allowed recipients keep their display name
+	toRedirected := e.Sandbox.Redirected(e.Recipient.Email)
+	if toRedirected {
+		to = redirected[0]
+	}
+
+	if toRedirected || slices.ContainsFunc(cc, e.Sandbox.Redirected) || slices.ContainsFunc(bcc, e.Sandbox.Redirected) {
+		e.sandboxHeaders(toRedirected, r, cc, bcc, headers)
+	}
+
+	e.Subject = fmt.Sprintf("[%s] %s", e.Sandbox.Name(), e.Subject)
+
+	return to, nil
+}
+
+// sandboxHeaders records the original recipients of an email with a rewritten address. The bcc addresses
+// are only recorded when the redirect address is the only recipient, otherwise they are logged so an
+// allowed recipient never sees them
+func (e EmailData) sandboxHeaders(toRedirected bool, r *recipients, cc, bcc []string, headers map[string]string) {
+	headers[SandboxOriginalToHeader] = e.Recipient.Email
+
+	if len(cc) > 0 {
+		headers[SandboxOriginalCCHeader] = strings.Join(cc, ", ")
+	}
+
+	if len(bcc) == 0 {
+		return
+	}
+
+	if toRedirected && len(r.cc) == 0 && len(r.bcc) == 0 {
+		headers[SandboxOriginalBCCHeader] = strings.Join(bcc, ", ")
+
+		return
+	}
+
+	log.Info().Str("template", e.Template).Strs("bcc", bcc).Msg("sandbox bcc addresses not recorded in the headers as allowed recipients receive the email")
+}
@@ -0,0 +1,106 @@
+package emailtemplates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxAllowed(t *testing.T) {
+	s := SandboxConfig{AllowedDomains: []string{"example.com", ".Test.dev"}}
+
+	assert.True(t, s.allowed("user@example.com"))
+	assert.True(t, s.allowed("user@mail.EXAMPLE.com"))
+	assert.True(t, s.allowed("user@test.dev"))
+	assert.False(t, s.allowed("user@notexample.com"))
+	assert.False(t, s.allowed("user@example.com.evil.io"))
+}
+
+func TestSandboxInEmail(t *testing.T) {
+	cfg := categoryConfig()
+	cfg.DisplayNames = true
+	WithSandbox("staging", "catch-all@example.com", "theopenlane.io")(&cfg)
+
+	email, err := cfg.NewBillingEmailChangedEmail(Recipient{Email: "owner@customer.com", FirstName: "Meow"}, BillingEmailChangedTemplateData{
+		OrganizationName: "Meow Inc.",
+		OldEmail:         "old@customer.com",
+		NewEmail:         "owner@customer.com",
+	}, WithCC("old@customer.com", "team@theopenlane.io"), WithBCC("audit@customer.com"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"catch-all@example.com"}, email.To)
+	assert.Equal(t, []string{"team@theopenlane.io"}, email.Cc)
+	assert.Empty(t, email.Bcc)
+	assert.Equal(t, "owner@customer.com", email.Headers[SandboxOriginalToHeader])
+	assert.Equal(t, "old@customer.com, team@theopenlane.io", email.Headers[SandboxOriginalCCHeader])
+	assert.NotContains(t, email.Headers, SandboxOriginalBCCHeader, "an allowed cc recipient must not see the bcc addresses")
+	assert.Regexp(t, `^\[staging\] `, email.Subject)
+	assert.Contains(t, email.HTML, `class="sandbox-banner"`)
+	assert.Contains(t, email.HTML, "originally addressed to owner@customer.com")
+	assert.Contains(t, email.HTML, "<strong>STAGING</strong>")
+	assert.Contains(t, email.Text, "*** STAGING *** This is a test email from the staging environment")
+
+	// the bcc addresses are recorded when the redirect address is the only recipient left
+	email, err = cfg.NewWelcomeEmail(Recipient{Email: "owner@customer.com"}, WithBCC("audit@customer.com"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"catch-all@example.com"}, email.To)
+	assert.Empty(t, email.Bcc)
+	assert.Equal(t, "audit@customer.com", email.Headers[SandboxOriginalBCCHeader])
+
+	// recipients on an allowed domain keep their address and display name
+	email, err = cfg.NewWelcomeEmail(Recipient{Email: "dev@theopenlane.io", FirstName: "Meow"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`"Meow" <dev@theopenlane.io>`}, email.To)
+	assert.NotContains(t, email.Headers, SandboxOriginalToHeader)
+
+	// the banner only names the original recipient when it was redirected
+	email, err = cfg.NewVerifyEmail(Recipient{Email: "dev@theopenlane.io"}, "token")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "This is a test email from the staging environment.</td>")
+	assert.Contains(t, email.Text, "This is a test email from the staging environment.")
+	assert.NotContains(t, email.Text, "originally addressed to")
+
+	// a redirected cc is recorded when the to address is allowed
+	email, err = cfg.NewWelcomeEmail(Recipient{Email: "dev@theopenlane.io"}, WithCC("customer@real.com"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev@theopenlane.io"}, email.To)
+	assert.Equal(t, []string{"catch-all@example.com"}, email.Cc)
+	assert.Equal(t, "dev@theopenlane.io", email.Headers[SandboxOriginalToHeader])
+	assert.Equal(t, "customer@real.com", email.Headers[SandboxOriginalCCHeader])
+
+	// a redirected bcc is not recorded when the to address is allowed, as the recipient would see it
+	email, err = cfg.NewWelcomeEmail(Recipient{Email: "dev@theopenlane.io"}, WithBCC("audit@customer.com", "qa@theopenlane.io"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"catch-all@example.com", "qa@theopenlane.io"}, email.Bcc)
+	assert.Equal(t, "dev@theopenlane.io", email.Headers[SandboxOriginalToHeader])
+	assert.NotContains(t, email.Headers, SandboxOriginalBCCHeader)
+
+	// without a redirect address recipients outside the allowed domains are dropped
+	cfg.Sandbox.Redirect = ""
+
+	_, err = cfg.NewWelcomeEmail(Recipient{Email: "owner@customer.com"})
+	require.ErrorIs(t, err, ErrSandboxRecipient)
+}
+
+func TestSandboxDisabled(t *testing.T) {
+	cfg := categoryConfig()
+
+	email, err := cfg.NewWelcomeEmail(Recipient{Email: "owner@customer.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"owner@customer.com"}, email.To)
+	assert.NotContains(t, email.Headers, SandboxOriginalToHeader)
+	assert.NotContains(t, email.HTML, `class="sandbox-banner"`)
+	assert.NotContains(t, email.Text, "test email")
+}
+
+func TestSandboxValidate(t *testing.T) {
+	require.NoError(t, SandboxConfig{}.validate())
+	require.NoError(t, SandboxConfig{Enabled: true, AllowedDomains: []string{"example.com"}}.validate())
+	require.NoError(t, SandboxConfig{Enabled: true, Redirect: "catch-all@example.com"}.validate())
+
+	var missing *MissingRequiredFieldError
+	require.ErrorAs(t, SandboxConfig{Enabled: true}.validate(), &missing)
+	require.ErrorIs(t, SandboxConfig{Enabled: true, Redirect: "catch-all"}.validate(), ErrInvalidAddress)
+}
@@ -0,0 +1,88 @@
+package emailtemplates
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// returnPathHeader is the bounce address of the email, providers supporting it use it as the envelope sender
+const returnPathHeader = "Return-Path"
+
+// SenderProfile is a sender identity used for the templates and categories it lists, e.g. billing emails
+// sent from billing@ with replies going to the billing team
+type SenderProfile struct {
+	// FromEmail is the address the email is sent from
+	FromEmail string `koanf:"fromemail" json:"fromemail"`
+	// FromName is the display name of the sender, rendered as a template with the data of the email
+	FromName string `koanf:"fromname" json:"fromname"`
+	// ReplyTo are the addresses replies are sent to when the template and email set none
+	ReplyTo []string `koanf:"replyto" json:"replyto"`
+	// ReturnPath is the address bounces are sent to
+	ReturnPath string `koanf:"returnpath" json:"returnpath"`
+	// Templates are the names of the templates sent with the profile
+	Templates []string `koanf:"templates" json:"templates"`
+	// Categories are the categories of the emails sent with the profile
+	Categories []Category `koanf:"categories" json:"categories"`
+}
+
+// senderProfile returns the profile of the email, a profile listing the template takes precedence over the
+// questionnaire address and then a profile listing the category
+func (e EmailData) senderProfile() SenderProfile {
+	names := slices.Sorted(maps.Keys(e.SenderProfiles))
+
+	for _, name := range names {
+		if profile := e.SenderProfiles[name]; slices.Contains(profile.Templates, e.Template) {
+			return profile
+		}
+	}
+
+	if e.Template == "questionnaire_auth" && e.QuestionnaireEmail != "" {
+		return SenderProfile{FromEmail: e.QuestionnaireEmail}
+	}
+
+	for _, name := range names {
+		if profile := e.SenderProfiles[name]; slices.Contains(profile.Categories, e.Category) {
+			return profile
+		}
+	}
+
+	return SenderProfile{}
+}
+
+// sender returns the address the email is sent from, the address of the sender profile takes
+// precedence over the from address
+func (e EmailData) sender() string {
+	return valueOr(e.senderProfile().FromEmail, e.FromEmail)
+}
+
+// senderName renders the display name of the sender with the data of the email, configured names of the
+// template take precedence over the sender profile and the built in names
+func (e EmailData) senderName(data any) (string, error) {
+	text, ok := e.SenderNames[e.Template]
+	if !ok {
+		text = e.senderProfile().FromName
+		ok = text != ""
+	}
+
+	if !ok {
+		text, ok = defaultSenderNames[e.Template]
+	}
+
+	if !ok {
+		return valueOr(e.FromName, e.CompanyName), nil
+	}
+
+	tmpl, err := template.New(e.Template).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	b := strings.Builder{}
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,92 @@
+package emailtemplates
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func senderProfileConfig() Config {
+	cfg := categoryConfig()
+	cfg.QuestionnaireEmail = "questionnaire@example.com"
+	maps.Copy(cfg.SenderProfiles, map[string]SenderProfile{
+		"billing": {
+			FromEmail:  "billing@example.com",
+			FromName:   "{{ .EmailData.CompanyName }} Billing",
+			ReplyTo:    []string{"billing-team@example.com"},
+			ReturnPath: "bounces@example.com",
+			Templates:  []string{"verify_billing", "billing_email_changed"},
+		},
+		"trust": {
+			FromEmail: "trust@example.com",
+			Templates: []string{"trust_center_auth", "trust_center_nda_request", "trust_center_nda_signed"},
+		},
+		"transactional": {
+			FromEmail:  "hello@example.com",
+			Categories: []Category{CategoryTransactional},
+		},
+	})
+
+	return cfg
+}
+
+func TestSender(t *testing.T) {
+	cfg := senderProfileConfig()
+
+	tests := []struct {
+		name     string
+		template string
+		category Category
+		expected string
+	}{
+		{name: "template profile over category sender", template: "verify_billing", category: CategorySecurity, expected: "billing@example.com"},
+		{name: "trust center profile", template: "trust_center_auth", category: CategorySecurity, expected: "trust@example.com"},
+		{name: "questionnaire shortcut", template: "questionnaire_auth", category: CategorySecurity, expected: "questionnaire@example.com"},
+		{name: "category profile", template: "invite", category: CategoryTransactional, expected: "hello@example.com"},
+		{name: "marketing profile", template: "subscribe", category: CategoryMarketing, expected: "news@example.com"},
+		{name: "from email", template: "welcome", category: CategoryProduct, expected: "no-reply@example.com"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := EmailData{Config: cfg, Template: tc.template, Category: tc.category}
+			assert.Equal(t, tc.expected, e.sender())
+		})
+	}
+
+	// a profile listing the questionnaire template takes precedence over the shortcut
+	cfg.SenderProfiles["questionnaire"] = SenderProfile{FromEmail: "assessments@example.com", Templates: []string{"questionnaire_auth"}}
+
+	e := EmailData{Config: cfg, Template: "questionnaire_auth", Category: CategorySecurity}
+	assert.Equal(t, "assessments@example.com", e.sender())
+}
+
+func TestSenderProfileInEmail(t *testing.T) {
+	cfg := senderProfileConfig()
+	cfg.DisplayNames = true
+	cfg.URLS.VerifyBilling = "https://console.example.com/verify-billing"
+
+	email, err := cfg.NewVerifyBillingEmail(Recipient{Email: "test@example.com"}, "token")
+	require.NoError(t, err)
+
+	assert.Equal(t, `"Test Company Billing" <billing@example.com>`, email.From)
+	assert.Equal(t, "billing-team@example.com", email.ReplyTo)
+	assert.Equal(t, "bounces@example.com", email.Headers[returnPathHeader])
+
+	// reply to addresses of the email replace those of the profile
+	email, err = cfg.NewVerifyBillingEmail(Recipient{Email: "test@example.com"}, "token", WithReplyTo("owner@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, "owner@example.com", email.ReplyTo)
+	assert.Empty(t, email.Headers[replyToHeader])
+
+	email, err = cfg.NewTrustCenterAuthEmail(Recipient{Email: "test@example.com"}, "token", TrustCenterAuthData{
+		OrganizationName: "Acme",
+		TrustCenterURL:   "https://trust.example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `"Acme Trust Center via Test Company" <trust@example.com>`, email.From)
+	assert.Empty(t, email.ReplyTo)
+	assert.NotContains(t, email.Headers, returnPathHeader)
+}
@@ -0,0 +1,103 @@
+package emailtemplates
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// shortCodeBase is the base the sequential codes of the MemoryShortener are formatted in
+const shortCodeBase = 36
+
+// Shortener shortens the action links of the emails, e.g. a client of a link shortening service
+type Shortener interface {
+	// Shorten returns a short URL that redirects to the long URL
+	Shorten(ctx context.Context, longURL string) (string, error)
+}
+
+// ShortLinkMode controls where the short link is used in an email
+type ShortLinkMode string
+
+const (
+	// ShortLinkBoth uses the short link as the href and the displayed text, this is the default
+	ShortLinkBoth ShortLinkMode = "both"
+	// ShortLinkHref uses the short link as the href while the long link is displayed
+	ShortLinkHref ShortLinkMode = "href"
+	// ShortLinkDisplay displays the short link while the href is the long link
+	ShortLinkDisplay ShortLinkMode = "display"
+)
+
+// shortLinks shortens the action link with the configured shortener and returns the href and the URL to
+// display, the long link is used for both when there is no shortener or shortening fails
+func (c Config) shortLinks(ctx context.Context, longURL string) (href, display string) {
+	if c.Shortener == nil {
+		return longURL, longURL
+	}
+
+	short, err := c.Shortener.Shorten(ctx, longURL)
+	if err != nil || short == "" {
+		log.Warn().Err(err).Msg("could not shorten link, using the long link")
+
+		return longURL, longURL
+	}
+
+	switch c.ShortLinkMode {
+	case ShortLinkHref:
+		return short, longURL
+	case ShortLinkDisplay:
+		return longURL, short
+	default:
+		return short, short
+	}
+}
+
+// MemoryShortener is an in memory Shortener that returns sequential codes on a base URL, meant for
+// tests and local development. The same long URL is always shortened to the same short URL
+type MemoryShortener struct {
+	base string
+
+	mu    sync.RWMutex
+	codes map[string]string
+	urls  map[string]string
+}
+
+// NewMemoryShortener returns a shortener creating short URLs on the base URL, e.g. https://go.example.com
+func NewMemoryShortener(base string) *MemoryShortener {
+	return &MemoryShortener{
+		base:  strings.TrimSuffix(base, "/"),
+		codes: map[string]string{},
+		urls:  map[string]string{},
+	}
+}
+
+// Shorten returns the short URL for the long URL
+func (m *MemoryShortener) Shorten(ctx context.Context, longURL string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	code, ok := m.codes[longURL]
+	if !ok {
+		code = strconv.FormatInt(int64(len(m.codes)+1), shortCodeBase)
+		m.codes[longURL] = code
+		m.urls[code] = longURL
+	}
+
+	return m.base + "/" + code, nil
+}
+
+// Resolve returns the long URL of a short URL created by the shortener
+func (m *MemoryShortener) Resolve(shortURL string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	longURL, ok := m.urls[strings.TrimPrefix(shortURL, m.base+"/")]
+
+	return longURL, ok
+}
@@ -0,0 +1,124 @@
+package emailtemplates
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errShortenerUnavailable = errors.New("shortener unavailable")
+
+// failingShortener always fails to shorten
+type failingShortener struct{}
+
+func (failingShortener) Shorten(context.Context, string) (string, error) {
+	return "", errShortenerUnavailable
+}
+
+func TestMemoryShortener(t *testing.T) {
+	s := NewMemoryShortener("https://go.example.com/")
+
+	short, err := s.Shorten(context.Background(), "https://console.example.com/verify?token=abc")
+	require.NoError(t, err)
+	assert.Equal(t, "https://go.example.com/1", short)
+
+	again, err := s.Shorten(context.Background(), "https://console.example.com/verify?token=abc")
+	require.NoError(t, err)
+	assert.Equal(t, short, again)
+
+	other, err := s.Shorten(context.Background(), "https://console.example.com/verify?token=def")
+	require.NoError(t, err)
+	assert.Equal(t, "https://go.example.com/2", other)
+
+	long, ok := s.Resolve(short)
+	require.True(t, ok)
+	assert.Equal(t, "https://console.example.com/verify?token=abc", long)
+
+	_, ok = s.Resolve("https://go.example.com/zz")
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = s.Shorten(ctx, "https://console.example.com")
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestShortLinks(t *testing.T) {
+	const longURL = "https://console.example.com/verify?token=abc"
+
+	tests := []struct {
+		name      string
+		shortener Shortener
+		mode      ShortLinkMode
+		href      string
+		display   string
+	}{
+		{
+			name:    "no shortener",
+			href:    longURL,
+			display: longURL,
+		},
+		{
+			name:      "default mode",
+			shortener: NewMemoryShortener("https://go.example.com"),
+			href:      "https://go.example.com/1",
+			display:   "https://go.example.com/1",
+		},
+		{
+			name:      "href only",
+			shortener: NewMemoryShortener("https://go.example.com"),
+			mode:      ShortLinkHref,
+			href:      "https://go.example.com/1",
+			display:   longURL,
+		},
+		{
+			name:      "display only",
+			shortener: NewMemoryShortener("https://go.example.com"),
+			mode:      ShortLinkDisplay,
+			href:      longURL,
+			display:   "https://go.example.com/1",
+		},
+		{
+			name:      "falls back to the long link",
+			shortener: failingShortener{},
+			href:      longURL,
+			display:   longURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{Shortener: tt.shortener, ShortLinkMode: tt.mode}
+
+			href, display := c.shortLinks(context.Background(), longURL)
+			assert.Equal(t, tt.href, href)
+			assert.Equal(t, tt.display, display)
+		})
+	}
+}
+
+func TestShortenedEmail(t *testing.T) {
+	shortener := NewMemoryShortener("https://go.example.com")
+
+	cfg := Config{
+		CompanyName: "Test Company",
+		FromEmail:   "no-reply@example.com",
+		URLS:        URLConfig{VerifyBilling: "https://console.example.com/verify-billing"},
+	}
+
+	WithShortener(shortener, ShortLinkDisplay)(&cfg)
+
+	email, err := cfg.NewVerifyBillingEmail(Recipient{Email: "test@example.com"}, "abc", WithContext(context.Background()))
+	require.NoError(t, err)
+
+	assert.Contains(t, email.HTML, `<a href="https://console.example.com/verify-billing?token=abc">https://go.example.com/1</a>`)
+	assert.Contains(t, email.Text, "https://go.example.com/1")
+
+	long, ok := shortener.Resolve("https://go.example.com/1")
+	require.True(t, ok)
+	assert.Equal(t, "https://console.example.com/verify-billing?token=abc", long)
+}
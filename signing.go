@@ -0,0 +1,222 @@
+package emailtemplates
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSignedURLTTL is how long signed URLs are valid when no TTL is configured
+const defaultSignedURLTTL = 24 * time.Hour
+
+// Query parameters added to signed URLs
+const (
+	signedEmailParam   = "email"
+	signedPurposeParam = "purpose"
+	signedExpiryParam  = "exp"
+	signedKeyIDParam   = "kid"
+	signedSigParam     = "sig"
+)
+
+// Purpose is the action a signed URL is valid for, so a link from one email cannot be used for another flow
+type Purpose string
+
+// Purposes of the signed URLs in the built in emails
+const (
+	PurposeVerify           Purpose = "verify"
+	PurposeInvite           Purpose = "invite"
+	PurposePasswordReset    Purpose = "password_reset"
+	PurposeVerifySubscriber Purpose = "verify_subscriber"
+	PurposeVerifyBilling    Purpose = "verify_billing"
+	PurposeTrustCenterNDA   Purpose = "trust_center_nda"
+	PurposeTrustCenterAuth  Purpose = "trust_center_auth"
+	PurposeQuestionnaire    Purpose = "questionnaire"
+	PurposeUnsubscribe      Purpose = "unsubscribe"
+)
+
+// SignedURLConfig configures HMAC signing of the action URLs in the emails. Keys are looked up by
+// their ID, so a new key can be made active while links signed with the previous one still verify
+type SignedURLConfig struct {
+	// Enabled signs the action URLs, the token passed to the builders becomes optional
+	Enabled bool `koanf:"enabled" json:"enabled" default:"false"`
+	// KeyID is the ID of the key in Keys used to sign new URLs
+	KeyID string `koanf:"keyid" json:"keyid" default:""`
+	// Keys are the signing secrets by key ID, keep retired keys until the links they signed expire
+	Keys map[string]string `koanf:"keys" json:"keys"`
+	// TTL is how long a signed URL is valid, defaults to 24 hours
+	TTL time.Duration `koanf:"ttl" json:"ttl" default:"24h"`
+}
+
+// SignedURLClaims are the values carried by a verified signed URL
+type SignedURLClaims struct {
+	// Email is the recipient the URL was sent to
+	Email string
+	// Purpose is the action the URL is valid for
+	Purpose Purpose
+	// ExpiresAt is when the URL stops being valid
+	ExpiresAt time.Time
+	// KeyID is the ID of the key the URL was signed with
+	KeyID string
+	// Token is the token parameter of the query or of a fragment such as #token=abc, a token under another
+	// parameter name or in a path segment is covered by the signature but is read from the URL by the caller
+	Token string
+}
+
+// ttl returns the configured TTL or the default
+func (s SignedURLConfig) ttl() time.Duration {
+	if s.TTL <= 0 {
+		return defaultSignedURLTTL
+	}
+
+	return s.TTL
+}
+
+// validate ensures the active signing key exists when signing is enabled
+func (s SignedURLConfig) validate() error {
+	if !s.Enabled {
+		return nil
+	}
+
+	v := validator{}
+
+	if _, err := s.key(s.KeyID); err != nil {
+		v.add("KeyID", err)
+	}
+
+	return v.err()
+}
+
+// key returns the secret of the key ID
+func (s SignedURLConfig) key(id string) ([]byte, error) {
+	secret, ok := s.Keys[id]
+	if !ok || secret == "" {
+		return nil, ErrSigningKeyNotFound
+	}
+
+	return []byte(secret), nil
+}
+
+// Sign adds the recipient, purpose, expiry and key ID to the URL along with a signature made with the
+// active key over the host, path, query parameters and fragment, so a token anywhere in the URL is
+// covered by the signature
+func (s SignedURLConfig) Sign(rawURL, email string, purpose Purpose, expiresAt time.Time) (string, error) {
+	secret, err := s.key(s.KeyID)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Del(signedSigParam)
+	query.Set(signedEmailParam, email)
+	query.Set(signedPurposeParam, string(purpose))
+	query.Set(signedExpiryParam, strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set(signedKeyIDParam, s.KeyID)
+	query.Set(signedSigParam, urlSignature(secret, u, query))
+
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// VerifySignedURL verifies the signature of a URL signed by the builders and that it is valid for the
+// purpose and has not expired, returning the claims it carries. The URL must be absolute as the host is
+// signed, use VerifySignedRequest in an http handler. A URL with a fragment is verified with the fragment,
+// which browsers do not send to the server, e.g. by the page reading a #token=abc fragment
+func VerifySignedURL(cfg SignedURLConfig, rawURL string, purpose Purpose) (*SignedURLClaims, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("%w: url has no host", ErrInvalidSignature)
+	}
+
+	query := u.Query()
+
+	secret, err := cfg.key(query.Get(signedKeyIDParam))
+	if err != nil {
+		return nil, err
+	}
+
+	sig := query.Get(signedSigParam)
+	query.Del(signedSigParam)
+
+	if sig == "" || !hmac.Equal([]byte(sig), []byte(urlSignature(secret, u, query))) {
+		return nil, ErrInvalidSignature
+	}
+
+	expiry, err := strconv.ParseInt(query.Get(signedExpiryParam), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	claims := &SignedURLClaims{
+		Email:     query.Get(signedEmailParam),
+		Purpose:   Purpose(query.Get(signedPurposeParam)),
+		ExpiresAt: time.Unix(expiry, 0),
+		KeyID:     query.Get(signedKeyIDParam),
+		Token:     urlToken(u, query),
+	}
+
+	if claims.Purpose != purpose {
+		return nil, ErrSignedURLPurpose
+	}
+
+	if time.Now().After(claims.ExpiresAt) {
+		return nil, ErrSignedURLExpired
+	}
+
+	return claims, nil
+}
+
+// VerifySignedRequest verifies the signed URL of a request like VerifySignedURL, the host is taken from the
+// Host header so proxies in front of the handler must preserve it. Requests carry no fragment, so links
+// signed with one fail verification here
+func VerifySignedRequest(cfg SignedURLConfig, r *http.Request, purpose Purpose) (*SignedURLClaims, error) {
+	return VerifySignedURL(cfg, "https://"+r.Host+r.URL.RequestURI(), purpose)
+}
+
+// urlToken returns the token parameter of the query, or of the fragment when the query has none
+func urlToken(u *url.URL, query url.Values) string {
+	if token := query.Get(tokenParam); token != "" {
+		return token
+	}
+
+	fragment, err := url.ParseQuery(u.Fragment)
+	if err != nil {
+		return ""
+	}
+
+	return fragment.Get(tokenParam)
+}
+
+// urlSignature returns the url safe HMAC-SHA256 of the canonical form of the URL, the lower case host,
+// the escaped path, the encoded query sorted by name and the escaped fragment, one per line
+func urlSignature(secret []byte, u *url.URL, query url.Values) string {
+	canonical := strings.Join([]string{strings.ToLower(u.Host), u.EscapedPath(), query.Encode(), u.EscapedFragment()}, "\n")
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(canonical))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signature returns the url safe HMAC-SHA256 of the encoded query, which sorts the parameters by name
+func signature(secret []byte, query url.Values) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(query.Encode()))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,196 @@
+package emailtemplates
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedURL(t *testing.T) {
+	cfg := SignedURLConfig{
+		Enabled: true,
+		KeyID:   "2026-01",
+		Keys:    map[string]string{"2026-01": "current-secret", "2025-01": "previous-secret"},
+	}
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	signed, err := cfg.Sign("https://console.example.com/verify?token=abc&org=meow", "test@example.com", PurposeVerify, expires)
+	require.NoError(t, err)
+
+	claims, err := VerifySignedURL(cfg, signed, PurposeVerify)
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", claims.Email)
+	assert.Equal(t, PurposeVerify, claims.Purpose)
+	assert.Equal(t, "2026-01", claims.KeyID)
+	assert.Equal(t, "abc", claims.Token)
+	assert.True(t, expires.Equal(claims.ExpiresAt))
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+
+	// the request URL seen by a handler is relative, the host comes from the request
+	_, err = VerifySignedURL(cfg, u.RequestURI(), PurposeVerify)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = VerifySignedRequest(cfg, httptest.NewRequest(http.MethodGet, signed, nil), PurposeVerify)
+	require.NoError(t, err)
+
+	t.Run("wrong purpose", func(t *testing.T) {
+		_, err := VerifySignedURL(cfg, signed, PurposePasswordReset)
+		require.ErrorIs(t, err, ErrSignedURLPurpose)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		query := u.Query()
+		query.Set("email", "attacker@example.com")
+
+		tampered := *u
+		tampered.RawQuery = query.Encode()
+
+		_, err := VerifySignedURL(cfg, tampered.String(), PurposeVerify)
+		require.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("tampered host or path", func(t *testing.T) {
+		pathSigned, err := cfg.Sign("https://console.example.com/verify/tokA", "test@example.com", PurposeVerify, expires)
+		require.NoError(t, err)
+
+		_, err = VerifySignedURL(cfg, pathSigned, PurposeVerify)
+		require.NoError(t, err)
+
+		otherPath := strings.Replace(pathSigned, "/verify/tokA", "/verify/tokB", 1)
+		_, err = VerifySignedURL(cfg, otherPath, PurposeVerify)
+		require.ErrorIs(t, err, ErrInvalidSignature)
+
+		otherHost := strings.Replace(pathSigned, "console.example.com", "evil.test", 1)
+		_, err = VerifySignedURL(cfg, otherHost, PurposeVerify)
+		require.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("token in fragment", func(t *testing.T) {
+		fragmentSigned, err := cfg.Sign("https://console.example.com/reset#token=tokA", "test@example.com", PurposePasswordReset, expires)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(fragmentSigned, "#token=tokA"))
+
+		claims, err := VerifySignedURL(cfg, fragmentSigned, PurposePasswordReset)
+		require.NoError(t, err)
+		assert.Equal(t, "tokA", claims.Token)
+
+		_, err = VerifySignedURL(cfg, strings.Replace(fragmentSigned, "#token=tokA", "#token=tokB", 1), PurposePasswordReset)
+		require.ErrorIs(t, err, ErrInvalidSignature)
+
+		// browsers do not send the fragment, so the request cannot be verified
+		requested, _, _ := strings.Cut(fragmentSigned, "#")
+
+		_, err = VerifySignedRequest(cfg, httptest.NewRequest(http.MethodGet, requested, nil), PurposePasswordReset)
+		require.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("token under another name or in the path", func(t *testing.T) {
+		for _, raw := range []string{"https://console.example.com/verify?code=tokA", "https://console.example.com/verify/tokA"} {
+			signed, err := cfg.Sign(raw, "test@example.com", PurposeVerify, expires)
+			require.NoError(t, err)
+
+			// the token is read from the URL by the caller, the claims only hold the token parameter
+			claims, err := VerifySignedURL(cfg, signed, PurposeVerify)
+			require.NoError(t, err)
+			assert.Empty(t, claims.Token)
+
+			_, err = VerifySignedURL(cfg, strings.Replace(signed, "tokA", "tokB", 1), PurposeVerify)
+			require.ErrorIs(t, err, ErrInvalidSignature)
+		}
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		_, err := VerifySignedURL(cfg, "https://console.example.com/verify?token=abc&kid=2026-01", PurposeVerify)
+		require.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired, err := cfg.Sign("https://console.example.com/verify", "test@example.com", PurposeVerify, time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+
+		_, err = VerifySignedURL(cfg, expired, PurposeVerify)
+		require.ErrorIs(t, err, ErrSignedURLExpired)
+	})
+
+	t.Run("rotated key", func(t *testing.T) {
+		previous := cfg
+		previous.KeyID = "2025-01"
+
+		old, err := previous.Sign("https://console.example.com/verify", "test@example.com", PurposeVerify, expires)
+		require.NoError(t, err)
+
+		_, err = VerifySignedURL(cfg, old, PurposeVerify)
+		require.NoError(t, err)
+
+		retired := cfg
+		retired.Keys = map[string]string{"2026-01": "current-secret"}
+
+		_, err = VerifySignedURL(retired, old, PurposeVerify)
+		require.ErrorIs(t, err, ErrSigningKeyNotFound)
+	})
+}
+
+func TestSignedURLsInEmails(t *testing.T) {
+	_, err := New(
+		WithCompanyName("Test Company"),
+		WithCompanyAddress("123 Test St"),
+		WithFromEmail("no-reply@example.com"),
+		WithSignedURLs("missing", "", time.Hour),
+	)
+	require.ErrorIs(t, err, ErrSigningKeyNotFound)
+
+	cfg := Config{
+		CompanyName: "Test Company",
+		FromEmail:   "no-reply@example.com",
+		URLS:        URLConfig{PasswordReset: "https://console.example.com/password-reset"},
+	}
+
+	WithSignedURLs("current", "secret", time.Hour)(&cfg)
+
+	r := Recipient{Email: "test@example.com"}
+
+	// the token is optional when the url is signed
+	email, err := cfg.NewPasswordResetRequestEmail(r, "")
+	require.NoError(t, err)
+
+	links := extractLinks(email.HTML)
+	require.NotEmpty(t, links)
+
+	claims, err := VerifySignedURL(cfg.SignedURLs, links[0], PurposePasswordReset)
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", claims.Email)
+	assert.Empty(t, claims.Token)
+	assert.Contains(t, email.Text, "this link will expire in 1 hour ("+claims.ExpiresAt.UTC().Format("January 2, 2006 at 15:04 MST")+")")
+
+	// the token is required when the url has a placeholder for it
+	placeholder := cfg
+	placeholder.URLS.PasswordReset = "https://console.example.com/password-reset/" + TokenPlaceholder
+
+	_, err = placeholder.NewPasswordResetRequestEmail(r, "")
+	require.Error(t, err)
+
+	email, err = placeholder.NewPasswordResetRequestEmail(r, "tokA")
+	require.NoError(t, err)
+
+	links = extractLinks(email.HTML)
+	require.NotEmpty(t, links)
+	assert.Contains(t, links[0], "/password-reset/tokA?")
+
+	_, err = VerifySignedURL(cfg.SignedURLs, links[0], PurposePasswordReset)
+	require.NoError(t, err)
+
+	// without signing the token is still required
+	cfg.SignedURLs.Enabled = false
+
+	_, err = cfg.NewPasswordResetRequestEmail(r, "")
+	require.Error(t, err)
+}
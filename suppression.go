@@ -0,0 +1,239 @@
+package emailtemplates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// suppressionFileMode is the permission of the file written by the FileSuppressionStore
+const suppressionFileMode = 0o600
+
+// SuppressionReason is why a recipient no longer receives emails
+type SuppressionReason string
+
+// Reasons a recipient is suppressed
+const (
+	// SuppressionBounce is set when emails to the recipient hard bounced
+	SuppressionBounce SuppressionReason = "bounce"
+	// SuppressionComplaint is set when the recipient marked an email as spam
+	SuppressionComplaint SuppressionReason = "complaint"
+	// SuppressionUnsubscribe is set when the recipient unsubscribed
+	SuppressionUnsubscribe SuppressionReason = "unsubscribe"
+	// SuppressionManual is set when the recipient was suppressed by hand, e.g. by support
+	SuppressionManual SuppressionReason = "manual"
+)
+
+// Suppression is a recipient on the suppression list
+type Suppression struct {
+	// Email is the suppressed recipient
+	Email string `json:"email"`
+	// Reason the recipient is suppressed
+	Reason SuppressionReason `json:"reason"`
+	// CreatedAt is when the recipient was suppressed
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SuppressionStore holds the recipients that no longer receive emails, it is consulted when an email
+// of a suppressible category is built
+type SuppressionStore interface {
+	// Suppression returns the suppression of the recipient, or nil when the recipient is not suppressed
+	Suppression(ctx context.Context, email string) (*Suppression, error)
+	// Suppress adds the recipient to the list, replacing an existing suppression
+	Suppress(ctx context.Context, s Suppression) error
+	// Unsuppress removes the recipient from the list
+	Unsuppress(ctx context.Context, email string) error
+}
+
+// normalizeEmail returns the email in the form it is stored in so lookups are case insensitive
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkSuppression returns a SuppressedError when the recipient is suppressed, emails of categories
+// that are not suppressible such as security bypass the check
+func (e EmailData) checkSuppression() error {
+	if e.Suppressions == nil || !e.Category.Suppressible() {
+		return nil
+	}
+
+	s, err := e.Suppressions.Suppression(e.context(), e.Recipient.Email)
+	if err != nil {
+		return err
+	}
+
+	if s == nil {
+		return nil
+	}
+
+	return &SuppressedError{Email: e.Recipient.Email, Reason: s.Reason}
+}
+
+// MemorySuppressionStore is a SuppressionStore kept in memory
+type MemorySuppressionStore struct {
+	mu           sync.RWMutex
+	suppressions map[string]Suppression
+}
+
+// NewMemorySuppressionStore returns an empty in memory suppression list
+func NewMemorySuppressionStore() *MemorySuppressionStore {
+	return &MemorySuppressionStore{suppressions: map[string]Suppression{}}
+}
+
+// Suppression returns the suppression of the recipient, or nil when the recipient is not suppressed
+func (m *MemorySuppressionStore) Suppression(_ context.Context, email string) (*Suppression, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.suppressions[normalizeEmail(email)]
+	if !ok {
+		return nil, nil
+	}
+
+	return &s, nil
+}
+
+// Suppress adds the recipient to the list, the time is set to now when it is zero
+func (m *MemorySuppressionStore) Suppress(_ context.Context, s Suppression) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.suppress(s)
+
+	return nil
+}
+
+// Unsuppress removes the recipient from the list
+func (m *MemorySuppressionStore) Unsuppress(_ context.Context, email string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.suppressions, normalizeEmail(email))
+
+	return nil
+}
+
+// List returns the suppressions sorted by email
+func (m *MemorySuppressionStore) List() []Suppression {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]Suppression, 0, len(m.suppressions))
+	for _, s := range m.suppressions {
+		list = append(list, s)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Email < list[j].Email })
+
+	return list
+}
+
+// suppress adds the suppression, the lock must be held
+func (m *MemorySuppressionStore) suppress(s Suppression) {
+	s.Email = normalizeEmail(s.Email)
+
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now().UTC()
+	}
+
+	m.suppressions[s.Email] = s
+}
+
+// FileSuppressionStore is a SuppressionStore kept in memory and written to a json file on every change
+type FileSuppressionStore struct {
+	*MemorySuppressionStore
+
+	// mu holds a change to the list until the file is written, so a concurrent change cannot replace it
+	// with an older list
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSuppressionStore returns a suppression list stored in the json file at path, the file is
+// created on the first change when it does not exist
+func NewFileSuppressionStore(path string) (*FileSuppressionStore, error) {
+	store := &FileSuppressionStore{MemorySuppressionStore: NewMemorySuppressionStore(), path: path}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Suppression
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	for _, s := range list {
+		store.suppress(s)
+	}
+
+	return store, nil
+}
+
+// Suppress adds the recipient to the list and writes the file
+func (f *FileSuppressionStore) Suppress(ctx context.Context, s Suppression) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemorySuppressionStore.Suppress(ctx, s); err != nil {
+		return err
+	}
+
+	return f.save()
+}
+
+// Unsuppress removes the recipient from the list and writes the file
+func (f *FileSuppressionStore) Unsuppress(ctx context.Context, email string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.MemorySuppressionStore.Unsuppress(ctx, email); err != nil {
+		return err
+	}
+
+	return f.save()
+}
+
+// save writes the list to a temporary file that replaces the file so it is never partially written, the
+// caller holds mu
+func (f *FileSuppressionStore) save() error {
+	data, err := json.MarshalIndent(f.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), suppressionFileMode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
@@ -0,0 +1,111 @@
+package emailtemplates
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySuppressionStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySuppressionStore()
+
+	s, err := store.Suppression(ctx, "test@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, s)
+
+	require.NoError(t, store.Suppress(ctx, Suppression{Email: " Test@Example.com", Reason: SuppressionBounce}))
+
+	s, err = store.Suppression(ctx, "TEST@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	assert.Equal(t, "test@example.com", s.Email)
+	assert.Equal(t, SuppressionBounce, s.Reason)
+	assert.False(t, s.CreatedAt.IsZero())
+
+	require.NoError(t, store.Unsuppress(ctx, "test@example.com"))
+
+	s, err = store.Suppression(ctx, "test@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestFileSuppressionStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "suppressions.json")
+
+	store, err := NewFileSuppressionStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Suppress(ctx, Suppression{Email: "a@example.com", Reason: SuppressionComplaint}))
+	require.NoError(t, store.Suppress(ctx, Suppression{Email: "b@example.com", Reason: SuppressionUnsubscribe}))
+	require.NoError(t, store.Unsuppress(ctx, "b@example.com"))
+
+	reloaded, err := NewFileSuppressionStore(path)
+	require.NoError(t, err)
+
+	list := reloaded.List()
+	require.Len(t, list, 1)
+	assert.Equal(t, "a@example.com", list[0].Email)
+	assert.Equal(t, SuppressionComplaint, list[0].Reason)
+}
+
+func TestFileSuppressionStoreConcurrent(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "suppressions.json")
+
+	store, err := NewFileSuppressionStore(path)
+	require.NoError(t, err)
+
+	const count = 50
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, count)
+
+	for i := range count {
+		wg.Go(func() {
+			errs <- store.Suppress(ctx, Suppression{Email: fmt.Sprintf("user%d@example.com", i), Reason: SuppressionBounce})
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	reloaded, err := NewFileSuppressionStore(path)
+	require.NoError(t, err)
+	assert.Len(t, reloaded.List(), count)
+}
+
+func TestSuppressionInEmail(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySuppressionStore()
+	require.NoError(t, store.Suppress(ctx, Suppression{Email: "test@example.com", Reason: SuppressionUnsubscribe}))
+
+	cfg := categoryConfig()
+	cfg.Suppressions = store
+
+	_, err := subscribe(SubscriberEmailData{
+		EmailData:        EmailData{Config: cfg, Recipient: Recipient{Email: "test@example.com"}},
+		OrganizationName: "Meow Inc.",
+	})
+	require.ErrorIs(t, err, ErrRecipientSuppressed)
+
+	var suppressed *SuppressedError
+	require.ErrorAs(t, err, &suppressed)
+	assert.Equal(t, SuppressionUnsubscribe, suppressed.Reason)
+
+	// security emails bypass the suppression list
+	_, err = verify(VerifyEmailData{
+		EmailData: EmailData{Config: cfg, Recipient: Recipient{Email: "test@example.com"}},
+	})
+	require.NoError(t, err)
+}
@@ -1,12 +1,26 @@
 package emailtemplates
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/theopenlane/newman"
 )
 
+// TemplateTag is the name of the message tag that holds the template an email was rendered from
+const TemplateTag = "template"
+
+// TokenPlaceholder is replaced with the token in action URLs, e.g. https://console.example.com/verify/{token}
+// or https://console.example.com/verify#token={token}. URLs without it get a token query parameter
+const TokenPlaceholder = "{token}"
+
+// tokenParam is the query parameter the token is added as when the URL has no TokenPlaceholder
+const tokenParam = "token"
+
 // Email subject lines
 const (
 	welcomeSubject               = "Welcome to %s!"
@@ -36,6 +50,14 @@ type Config struct {
 	Year int `koanf:"year" json:"year" default:""`
 	// FromEmail is the email address that the email is sent from
 	FromEmail string `koanf:"fromemail" json:"fromemail" default:"" domain:"inherit" domainPrefix:"no-reply@mail"`
+	// FromName is the display name of the sender, defaults to the company name
+	FromName string `koanf:"fromname" json:"fromname" default:""`
+	// SenderNames are the display names of the sender by template name, as templates rendered with the
+	// data of the email, e.g. {{ .OrganizationName }} Trust Center via {{ .EmailData.CompanyName }}
+	SenderNames map[string]string `koanf:"sendernames" json:"sendernames"`
+	// DisplayNames adds the display names of the sender and recipient to the from and to addresses,
+	// the sender must accept RFC 5322 addresses rather than bare email addresses
+	DisplayNames bool `koanf:"displaynames" json:"displaynames" default:"false"`
 	// SupportEmail is the email address that the recipient can contact for support
 	SupportEmail string `koanf:"supportemail" json:"supportemail" default:"" domain:"inherit" domainPrefix:"support@"`
 	// QuestionnaireEmail is the email address for questionnaire/assessment related emails.
@@ -43,13 +65,38 @@ type Config struct {
 	QuestionnaireEmail string `koanf:"questionnaireemail" json:"questionnaireemail" default:"" domain:"inherit" domainPrefix:"questionnaire@"`
 	// LogoURL is the URL to the company logo that is included in the email if provided
 	LogoURL string `koanf:"logourl" json:"logourl" default:""`
+	// Locale is the language durations and timestamps are formatted in, e.g. en or es-MX
+	Locale string `koanf:"locale" json:"locale" default:"en"`
 	// URLS includes URLs that are used in the email templates
 	URLS URLConfig `koanf:"urls" json:"urls"`
 	// TemplatesPath is the path to the email templates to override the default templates
 	TemplatesPath string `koanf:"templatespath" json:"templatespath" default:""`
+	// LinkValidation configures the checks applied to the links in the html of every email
+	LinkValidation LinkValidation `koanf:"linkvalidation" json:"linkvalidation"`
+	// SignedURLs configures signing of the action URLs with an expiry, see VerifySignedURL
+	SignedURLs SignedURLConfig `koanf:"signedurls" json:"signedurls"`
+	// Shortener shortens the action links when set, the long link is used if shortening fails
+	Shortener Shortener `koanf:"-" json:"-"`
+	// ShortLinkMode controls whether the short link is used as the href, the displayed text or both
+	ShortLinkMode ShortLinkMode `koanf:"shortlinkmode" json:"shortlinkmode" default:"both"`
+	// UTM configures the campaign parameters added to the links of the emails
+	UTM UTMConfig `koanf:"utm" json:"utm"`
+	// Tracking configures tracking of the emails through the tracking domain
+	Tracking TrackingConfig `koanf:"tracking" json:"tracking"`
+	// Categories override the category of templates by name, e.g. to mark a custom template as marketing
+	Categories map[string]Category `koanf:"categories" json:"categories"`
+	// SenderProfiles are the sender identities by name, used for the templates and categories they list
+	SenderProfiles map[string]SenderProfile `koanf:"senderprofiles" json:"senderprofiles"`
+	// Suppressions are consulted when an email of a suppressible category is built, see ErrRecipientSuppressed
+	Suppressions SuppressionStore `koanf:"-" json:"-"`
+	// TemplateRecipients are the addresses copied on or replied to by every email of a template, by template name
+	TemplateRecipients map[string]RecipientDefaults `koanf:"templaterecipients" json:"templaterecipients"`
+	// Sandbox redirects the emails built in non-production environments
+	Sandbox SandboxConfig `koanf:"sandbox" json:"sandbox"`
 }
 
-// URLConfig includes urls that are used in the email templates
+// URLConfig includes urls that are used in the email templates, the action URLs that carry a token
+// may include the TokenPlaceholder to control where the token is placed
 type URLConfig struct {
 	// Root is the root domain for the email
 	Root string `koanf:"root" json:"root" default:"" domain:"inherit" domainPrefix:"https://www"`
@@ -69,6 +116,12 @@ type URLConfig struct {
 	VerifyBilling string `koanf:"verifybilling" json:"verifybilling" default:"" domain:"inherit" domainPrefix:"https://console" domainSuffix:"/verify-billing"`
 	// Questionnaire is the URL to access a questionnaire
 	Questionnaire string `koanf:"questionnaire" json:"questionnaire" default:"" domain:"inherit" domainPrefix:"https://console" domainSuffix:"/questionnaire"`
+	// TermsOfService is the URL to the terms of service linked in the footer, defaults to legal/terms-of-service on the root domain
+	TermsOfService string `koanf:"termsofservice" json:"termsofservice" default:"" domain:"inherit" domainPrefix:"https://www" domainSuffix:"/legal/terms-of-service"`
+	// Privacy is the URL to the privacy policy linked in the footer, defaults to legal/privacy on the root domain
+	Privacy string `koanf:"privacy" json:"privacy" default:"" domain:"inherit" domainPrefix:"https://www" domainSuffix:"/legal/privacy"`
+	// Unsubscribe is the URL of the unsubscribe endpoint, a signed per-recipient token is added to it
+	Unsubscribe string `koanf:"unsubscribe" json:"unsubscribe" default:"" domain:"inherit" domainPrefix:"https://console" domainSuffix:"/unsubscribe"`
 }
 
 // EmailData includes data fields that are common to all the email builders
@@ -78,6 +131,46 @@ type EmailData struct {
 	Subject string `json:"subject"`
 	// Recipient is the person who will receive the email
 	Recipient Recipient `json:"recipient"`
+	// CC are the addresses copied on the email, after the defaults of the template
+	CC []string `json:"cc,omitempty"`
+	// BCC are the addresses blind copied on the email, after the defaults of the template
+	BCC []string `json:"bcc,omitempty"`
+	// ReplyTo are the addresses replies are sent to, after the defaults of the template
+	ReplyTo []string `json:"reply_to,omitempty"`
+	// Headers are added to the message, the headers set by Build take precedence
+	Headers map[string]string `json:"headers,omitempty"`
+	// Tags are added to the message as provider tags and metadata, after the tags set by Build
+	Tags map[string]string `json:"tags,omitempty"`
+	// Attachments are attached to the message
+	Attachments []*newman.Attachment `json:"-"`
+	// SendAt is when the email should be sent, added as the send_at tag for senders that schedule emails
+	SendAt time.Time `json:"send_at,omitempty"`
+	// Priority adds the X-Priority and Importance headers to the message
+	Priority Priority `json:"priority,omitempty"`
+	// Template is the name of the template the email was rendered from, it is added to the
+	// message as a tag so it can be identified after it is built
+	Template string `json:"template,omitempty"`
+	// Category is the kind of message the email is, set from the template when it is built
+	Category Category `json:"category,omitempty"`
+	// LinkExpiresAt is when the action link in the email stops working, zero when it does not expire
+	LinkExpiresAt time.Time `json:"link_expires_at,omitempty"`
+	// LinkTTL is how long the action link in the email is valid for, zero when it does not expire
+	LinkTTL time.Duration `json:"link_ttl,omitempty"`
+	// DisplayURL is shown in place of the action link when it differs from the href, e.g. a short link
+	DisplayURL string `json:"display_url,omitempty"`
+	// MessageID identifies the email in tracking events, generated when tracking is enabled and it is not set
+	MessageID string `json:"message_id,omitempty"`
+	// OpenPixelURL is the source of the open tracking pixel added by the base layouts, empty when opens are not tracked
+	OpenPixelURL string `json:"open_pixel_url,omitempty"`
+	// UnsubscribeURL is the recipient's unsubscribe link with its signed token, empty when no unsubscribe URL is configured
+	UnsubscribeURL string `json:"unsubscribe_url,omitempty"`
+
+	// subject replaces the subject of the template, set with WithSubject
+	subject string
+	// ctx is passed to the services called while building the email, e.g. the Shortener
+	ctx context.Context
+	// actionLinks are the action links generated by the builders, they carry the token or signature
+	actionLinks []string
 }
 
 // Recipient includes fields for the recipient of the email
@@ -88,6 +181,8 @@ type Recipient struct {
 	FirstName string `json:"first_name"`
 	// LastName is the last name of the recipient
 	LastName string `json:"last_name"`
+	// DoNotTrack disables click and open tracking for the recipient
+	DoNotTrack bool `json:"do_not_track,omitempty"`
 }
 
 // WelcomeData includes fields for the welcome email
@@ -190,18 +285,82 @@ type BillingEmailChangedData struct {
 
 // Build validates and creates a new email from pre-rendered templates
 func (e EmailData) Build(text, html string) (*newman.EmailMessage, error) {
+	if e.subject != "" {
+		e.Subject = e.subject
+	}
+
 	if err := e.Validate(); err != nil {
 		return nil, err
 	}
 
-	opts :=
-		[]newman.MessageOption{
-			newman.WithTo([]string{e.Recipient.Email}),
-			newman.WithFrom(e.FromEmail),
-			newman.WithSubject(e.Subject),
-			newman.WithHTML(html),
-			newman.WithText(text),
+	if err := e.checkSuppression(); err != nil {
+		return nil, err
+	}
+
+	recipients, err := e.recipients()
+	if err != nil {
+		return nil, err
+	}
+
+	html = e.addUTMParams(html, rewriteLinks)
+	text = e.addUTMParams(text, rewriteTextLinks)
+
+	if err := e.validateLinks(e.Template, html); err != nil {
+		return nil, err
+	}
+
+	if e.MessageID == "" && e.Tracking.Clicks && e.tracked() {
+		e.MessageID = newMessageID()
+	}
+
+	html = e.trackClicks(html)
+
+	headers := e.messageHeaders()
+	maps.Copy(headers, e.unsubscribeHeaders())
+
+	from, to := e.addresses()
+
+	if e.Sandbox.Enabled {
+		if to, err = e.sandbox(to, &recipients, headers); err != nil {
+			return nil, err
 		}
+	}
+
+	opts := []newman.MessageOption{
+		newman.WithTo([]string{to}),
+		newman.WithFrom(from),
+		newman.WithSubject(e.Subject),
+		newman.WithHTML(html),
+		newman.WithText(text),
+	}
+
+	if tags := e.messageTags(); len(tags) > 0 {
+		opts = append(opts, newman.WithTags(tags))
+	}
+
+	if len(e.Attachments) > 0 {
+		opts = append(opts, newman.WithAttachments(e.Attachments))
+	}
+
+	if len(recipients.cc) > 0 {
+		opts = append(opts, newman.WithCc(recipients.cc))
+	}
+
+	if len(recipients.bcc) > 0 {
+		opts = append(opts, newman.WithBcc(recipients.bcc))
+	}
+
+	// the message holds a single reply to address, several are only listed in the header
+	switch {
+	case len(recipients.replyTo) == 1:
+		opts = append(opts, newman.WithReplyTo(recipients.replyTo[0]))
+	case len(recipients.replyTo) > 1:
+		headers[replyToHeader] = strings.Join(recipients.replyTo, ", ")
+	}
+
+	if len(headers) > 0 {
+		opts = append(opts, newman.WithHeaders(headers))
+	}
 
 	return newman.NewEmailMessageWithOptions(opts...), nil
 }
@@ -218,158 +377,150 @@ func (e EmailData) Validate() error {
 	return nil
 }
 
-// verify creates a new email to verify an email address
-func verify(data VerifyEmailData) (*newman.EmailMessage, error) {
-	text, html, err := Render("verify_email", data)
-	if err != nil {
-		return nil, err
+// Subject returns the subject line of the named built-in email for the provided template data,
+// or an empty string if the data does not belong to a built-in email
+func Subject(name string, data interface{}) string {
+	switch d := data.(type) {
+	case VerifyEmailData:
+		return fmt.Sprintf(verifyEmailSubject, d.CompanyName)
+	case WelcomeData:
+		return fmt.Sprintf(welcomeSubject, d.CompanyName)
+	case InviteData:
+		// the invite and invite accepted emails share the same data
+		if name == "invite_joined" {
+			return fmt.Sprintf(inviteAcceptedSubject, d.CompanyName)
+		}
+
+		return fmt.Sprintf(inviteSubject, d.InviterName, d.CompanyName)
+	case ResetRequestData:
+		return fmt.Sprintf(passwordResetRequestSubject, d.CompanyName)
+	case ResetSuccessData:
+		return fmt.Sprintf(passwordResetSuccessSubject, d.CompanyName)
+	case SubscriberEmailData:
+		return fmt.Sprintf(subscribedSubject, d.CompanyName)
+	case VerifyBillingEmailData:
+		return fmt.Sprintf(verifyBillingSubject, d.CompanyName)
+	case TrustCenterNDARequestEmailData:
+		return fmt.Sprintf(trustCenterNDARequestSubject, d.OrganizationName)
+	case TrustCenterNDASignedEmailData:
+		return fmt.Sprintf(trustCenterNDASignedSubject, d.OrganizationName)
+	case TrustCenterAuthEmailData:
+		return fmt.Sprintf(trustCenterAuthSubject, d.OrganizationName)
+	case QuestionnaireAuthEmailData:
+		return fmt.Sprintf(questionnaireAuthSubject, d.AssessmentName, d.CompanyName)
+	case BillingEmailChangedData:
+		return fmt.Sprintf(billingEmailChangedSubject, d.OrganizationName)
 	}
 
-	data.Subject = fmt.Sprintf(verifyEmailSubject, data.CompanyName)
+	return ""
+}
 
-	return data.Build(text, html)
+// templateData is implemented by all template data through the embedded EmailData
+type templateData interface {
+	emailData() *EmailData
 }
 
-// welcome creates a new email to welcome a new user
-func welcome(data WelcomeData) (*newman.EmailMessage, error) {
-	text, html, err := Render("welcome", data)
-	if err != nil {
-		return nil, err
+// emailData returns the common email data for the generic build path
+func (e *EmailData) emailData() *EmailData {
+	return e
+}
+
+// build renders the named template with data and builds the message, it is the shared
+// path for every built in email so subjects and message metadata are set consistently
+func build(name string, data templateData) (*newman.EmailMessage, error) {
+	e := data.emailData()
+	e.Template = name
+	e.Category = e.CategoryOf(name)
+	e.FromEmail = e.sender()
+
+	if e.DisplayNames {
+		fromName, err := e.senderName(data)
+		if err != nil {
+			return nil, err
+		}
+
+		e.FromName = fromName
 	}
 
-	data.Subject = fmt.Sprintf(welcomeSubject, data.CompanyName)
+	e.Subject = Subject(name, reflect.Indirect(reflect.ValueOf(data)).Interface())
 
-	return data.Build(text, html)
-}
+	if err := e.prepareTracking(); err != nil {
+		return nil, err
+	}
 
-// invite creates a new email to invite a user to an organization
-func invite(data InviteData) (*newman.EmailMessage, error) {
-	text, html, err := Render("invite", data)
+	if err := e.prepareUnsubscribe(); err != nil {
+		return nil, err
+	}
+
+	text, html, err := Render(name, data)
 	if err != nil {
 		return nil, err
 	}
 
-	data.Subject = fmt.Sprintf(inviteSubject, data.InviterName, data.CompanyName)
+	return e.Build(text, html)
+}
 
-	return data.Build(text, html)
+// verify creates a new email to verify an email address
+func verify(data VerifyEmailData) (*newman.EmailMessage, error) {
+	return build("verify_email", &data)
 }
 
-// inviteAccepted creates a new email to notify a user that their invite has been accepted
-func inviteAccepted(data InviteData) (*newman.EmailMessage, error) {
-	text, html, err := Render("invite_joined", data)
-	if err != nil {
-		return nil, err
-	}
+// welcome creates a new email to welcome a new user
+func welcome(data WelcomeData) (*newman.EmailMessage, error) {
+	return build("welcome", &data)
+}
 
-	data.Subject = fmt.Sprintf(inviteAcceptedSubject, data.CompanyName)
+// invite creates a new email to invite a user to an organization
+func invite(data InviteData) (*newman.EmailMessage, error) {
+	return build("invite", &data)
+}
 
-	return data.Build(text, html)
+// inviteAccepted creates a new email to notify a user that their invite has been accepted
+func inviteAccepted(data InviteData) (*newman.EmailMessage, error) {
+	return build("invite_joined", &data)
 }
 
 // passwordResetRequest creates a new email to request a password reset
 func passwordResetRequest(data ResetRequestData) (*newman.EmailMessage, error) {
-	text, html, err := Render("password_reset_request", data)
-	if err != nil {
-		return nil, err
-	}
-
-	data.Subject = fmt.Sprintf(passwordResetRequestSubject, data.CompanyName)
-
-	return data.Build(text, html)
+	return build("password_reset_request", &data)
 }
 
 // passwordResetSuccess creates a new email to confirm a password reset
 func passwordResetSuccess(data ResetSuccessData) (*newman.EmailMessage, error) {
-	text, html, err := Render("password_reset_success", data)
-	if err != nil {
-		return nil, err
-	}
-
-	data.Subject = fmt.Sprintf(passwordResetSuccessSubject, data.CompanyName)
-
-	return data.Build(text, html)
+	return build("password_reset_success", &data)
 }
 
 // subscribe creates a new email to confirm a subscription
 func subscribe(data SubscriberEmailData) (*newman.EmailMessage, error) {
-	text, html, err := Render("subscribe", data)
-	if err != nil {
-		return nil, err
-	}
-
-	data.Subject = fmt.Sprintf(subscribedSubject, data.CompanyName)
-
-	return data.Build(text, html)
+	return build("subscribe", &data)
 }
 
 // verifyBilling creates a new email to verify a billing account
 func verifyBilling(data VerifyBillingEmailData) (*newman.EmailMessage, error) {
-	text, html, err := Render("verify_billing", data)
-	if err != nil {
-		return nil, err
-	}
-
-	data.Subject = fmt.Sprintf(verifyBillingSubject, data.CompanyName)
-
-	return data.Build(text, html)
+	return build("verify_billing", &data)
 }
 
 // trustCenterNDARequest creates a new email to request an NDA for the trust center
 func trustCenterNDARequest(data TrustCenterNDARequestEmailData) (*newman.EmailMessage, error) {
-	text, html, err := Render("trust_center_nda_request", data)
-	if err != nil {
-		return nil, err
-	}
-
-	data.Subject = fmt.Sprintf(trustCenterNDARequestSubject, data.OrganizationName)
-
-	return data.Build(text, html)
+	return build("trust_center_nda_request", &data)
 }
 
 // trustCenterNDASigned creates a new email to notify a user that their NDA has been signed
 func trustCenterNDASigned(data TrustCenterNDASignedEmailData) (*newman.EmailMessage, error) {
-	text, html, err := Render("trust_center_nda_signed", data)
-	if err != nil {
-		return nil, err
-	}
-
-	data.Subject = fmt.Sprintf(trustCenterNDASignedSubject, data.OrganizationName)
-
-	return data.Build(text, html)
+	return build("trust_center_nda_signed", &data)
 }
 
 // trustCenterAuth creates a new email with an auth link for the trust center
 func trustCenterAuth(data TrustCenterAuthEmailData) (*newman.EmailMessage, error) {
-	text, html, err := Render("trust_center_auth", data)
-	if err != nil {
-		return nil, err
-	}
-
-	data.Subject = fmt.Sprintf(trustCenterAuthSubject, data.OrganizationName)
-
-	return data.Build(text, html)
+	return build("trust_center_auth", &data)
 }
 
 // questionnaireAuth creates a new email with an auth link for the questionnaire
 func questionnaireAuth(data QuestionnaireAuthEmailData) (*newman.EmailMessage, error) {
-	text, html, err := Render("questionnaire_auth", data)
-	if err != nil {
-		return nil, err
-	}
-
-	data.Subject = fmt.Sprintf(questionnaireAuthSubject, data.AssessmentName, data.CompanyName)
-
-	return data.Build(text, html)
+	return build("questionnaire_auth", &data)
 }
 
 // billingEmailChanged creates a new email to notify about a billing email change
 func billingEmailChanged(data BillingEmailChangedData) (*newman.EmailMessage, error) {
-	text, html, err := Render("billing_email_changed", data)
-	if err != nil {
-		return nil, err
-	}
-
-	data.Subject = fmt.Sprintf(billingEmailChangedSubject, data.OrganizationName)
-
-	return data.Build(text, html)
+	return build("billing_email_changed", &data)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/theopenlane/newman"
 )
 
 func TestValidate(t *testing.T) {
@@ -76,6 +77,7 @@ func TestVerify(t *testing.T) {
 	require.NotNil(t, email)
 
 	assert.Equal(t, "Please verify your email address to login to Test Company", email.Subject)
+	assert.Contains(t, email.Tags, newman.Tag{Name: TemplateTag, Value: "verify_email"})
 }
 
 func TestWelcome(t *testing.T) {
@@ -251,6 +253,7 @@ func TestInviteWithNew(t *testing.T) {
 		WithCompanyName("Test Company"),
 		WithCompanyAddress("123 Test St"),
 		WithFromEmail("test@example.com"),
+		WithInviteURL("https://console.example.com/invite"),
 	)
 	require.NoError(t, err)
 
@@ -295,6 +298,7 @@ func TestInviteWithNewDefaultTemplate(t *testing.T) {
 		WithCompanyName("Test Company"),
 		WithCompanyAddress("123 Test St"),
 		WithFromEmail("test@example.com"),
+		WithInviteURL("https://console.example.com/invite"),
 	)
 	require.NoError(t, err)
 
@@ -0,0 +1,105 @@
+package emailtemplates
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// TemplateSet is an isolated collection of parsed email templates. Unlike the package level
+// templates, which are loaded once, a TemplateSet can be loaded (and reloaded) from any
+// directory, which makes it useful for tooling such as previews and linting
+type TemplateSet struct {
+	// Path is the custom templates directory the set was loaded from, empty for the defaults
+	Path string
+
+	templates map[string]*template.Template
+	partials  map[string]struct{}
+}
+
+// LoadTemplateSet parses the embedded default templates and overlays any templates found in path,
+// which must be laid out the same way as the directory passed to WithTemplatesPath.
+// An empty path returns only the embedded default templates
+func LoadTemplateSet(path string) (*TemplateSet, error) {
+	set := &TemplateSet{
+		Path:      path,
+		templates: map[string]*template.Template{},
+		partials:  map[string]struct{}{},
+	}
+
+	templateFiles, err := fs.ReadDir(files, defaultTemplatesDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read embedded template files: %w", err)
+	}
+
+	for _, file := range templateFiles {
+		if file.IsDir() {
+			continue
+		}
+
+		set.templates[file.Name()], err = parseTemplate(file.Name())
+		if err != nil {
+			return nil, fmt.Errorf("could not parse template %q: %w", file.Name(), err)
+		}
+	}
+
+	if path == "" || path == defaultTemplatesDir {
+		return set, nil
+	}
+
+	customPartials, err := getPartials(set.templates, path)
+	if err != nil {
+		return nil, fmt.Errorf("could not load partials from %q: %w", path, err)
+	}
+
+	for _, partial := range customPartials {
+		set.partials[filepath.Base(partial)] = struct{}{}
+	}
+
+	if err := loadTemplatesFromDir(set.templates, path, customPartials); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// Names returns the sorted base names of the templates in the set that have both
+// a text and html version, e.g. "welcome" for welcome.txt and welcome.html
+func (s *TemplateSet) Names() []string {
+	names := []string{}
+
+	for key := range s.templates {
+		if filepath.Ext(key) != ".html" {
+			continue
+		}
+
+		if _, ok := s.partials[key]; ok {
+			continue
+		}
+
+		name := strings.TrimSuffix(key, ".html")
+		if _, ok := s.templates[name+".txt"]; ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Render returns the text and html executed templates from the set for the specified name and data
+func (s *TemplateSet) Render(name string, data interface{}) (text, html string, err error) {
+	if text, err = renderFrom(s.templates, name+".txt", data); err != nil {
+		return
+	}
+
+	if html, err = renderFrom(s.templates, name+".html", data); err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,57 @@
+package emailtemplates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTemplateSet(t *testing.T) {
+	t.Run("embedded defaults", func(t *testing.T) {
+		set, err := LoadTemplateSet("")
+		require.NoError(t, err)
+
+		names := set.Names()
+		assert.Contains(t, names, "welcome")
+		assert.Contains(t, names, "trust_center_auth")
+		assert.NotContains(t, names, "base")
+
+		text, html, err := set.Render("welcome", WelcomeData{
+			EmailData: EmailData{
+				Config: Config{CompanyName: "Test Company"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, text, "Test Company")
+		assert.Contains(t, html, "Test Company")
+	})
+
+	t.Run("custom templates overlay the defaults", func(t *testing.T) {
+		set, err := LoadTemplateSet("testdata")
+		require.NoError(t, err)
+
+		assert.Contains(t, set.Names(), "verify_email")
+
+		_, html, err := set.Render("invite", InviteData{
+			EmailData: EmailData{
+				Config: Config{CompanyName: "Test Company"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, html, "custom invite email template")
+	})
+
+	t.Run("missing template", func(t *testing.T) {
+		set, err := LoadTemplateSet("")
+		require.NoError(t, err)
+
+		_, _, err = set.Render("does_not_exist", nil)
+		require.ErrorIs(t, err, ErrMissingTemplate)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := LoadTemplateSet("testdata/does-not-exist")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,332 @@
+package emailtemplates
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// MessageIDTag is the name of the message tag that holds the ID used to correlate tracking events
+const MessageIDTag = "message_id"
+
+// Query parameters of the tracking redirect URLs
+const (
+	trackURLParam      = "u"
+	trackTemplateParam = "t"
+	trackMessageParam  = "m"
+	trackPurposeParam  = "p"
+	trackSigParam      = "s"
+
+	// trackClickPurpose and trackOpenPurpose are signed with the values so a pixel URL cannot be used as
+	// a click URL and the other way around
+	trackClickPurpose = "click"
+	trackOpenPurpose  = "open"
+
+	// messageIDBytes is the number of random bytes in a generated message ID
+	messageIDBytes = 16
+)
+
+// trackParams are the signed query parameters of the tracking URLs, other parameters of the ClickURL or
+// OpenURL are kept and not signed
+var trackParams = []string{trackURLParam, trackTemplateParam, trackMessageParam, trackPurposeParam}
+
+// TrackingConfig configures tracking of the emails through redirect links on a tracking domain
+type TrackingConfig struct {
+	// Clicks rewrites the links in the html to redirect through the ClickURL so clicks can be recorded
+	Clicks bool `koanf:"clicks" json:"clicks" default:"false"`
+	// ClickURL is the URL the ClickHandler is served on, e.g. https://click.example.com/c
+	ClickURL string `koanf:"clickurl" json:"clickurl" default:""`
+	// Opens adds a pixel served from the OpenURL to the html so opens can be recorded
+	Opens bool `koanf:"opens" json:"opens" default:"false"`
+	// OpenURL is the URL the OpenHandler is served on, e.g. https://click.example.com/o.gif
+	OpenURL string `koanf:"openurl" json:"openurl" default:""`
+	// Secret signs the tracking URLs so they cannot be used as an open redirect
+	Secret string `koanf:"secret" json:"secret" default:""`
+	// ExcludeTemplates are not tracked, e.g. emails with security sensitive links such as password_reset_request
+	ExcludeTemplates []string `koanf:"excludetemplates" json:"excludetemplates"`
+	// ExcludeCategories are not tracked, e.g. security emails
+	ExcludeCategories []Category `koanf:"excludecategories" json:"excludecategories"`
+}
+
+// validate ensures the tracking URLs and secret are set when tracking is enabled
+func (t TrackingConfig) validate() error {
+	v := validator{}
+
+	if t.Clicks && v.required("ClickURL", "tracking click url", t.ClickURL) {
+		v.url("ClickURL", t.ClickURL)
+	}
+
+	if t.Opens && v.required("OpenURL", "tracking open url", t.OpenURL) {
+		v.url("OpenURL", t.OpenURL)
+	}
+
+	if t.Clicks || t.Opens {
+		v.required("Secret", "tracking secret", t.Secret)
+	}
+
+	return v.err()
+}
+
+// tracked reports whether the email is tracked, emails of excluded templates or categories and emails
+// to recipients who opted out of tracking are not
+func (e EmailData) tracked() bool {
+	return !e.Recipient.DoNotTrack &&
+		!slices.Contains(e.Tracking.ExcludeTemplates, e.Template) &&
+		!slices.Contains(e.Tracking.ExcludeCategories, e.Category)
+}
+
+// prepareTracking sets the message ID and the open pixel URL before the email is rendered
+func (e *EmailData) prepareTracking() error {
+	if !e.tracked() || (!e.Tracking.Clicks && !e.Tracking.Opens) {
+		return nil
+	}
+
+	if e.MessageID == "" {
+		e.MessageID = newMessageID()
+	}
+
+	if !e.Tracking.Opens {
+		return nil
+	}
+
+	var err error
+
+	e.OpenPixelURL, err = e.Tracking.signedURL(e.Tracking.OpenURL, trackOpenPurpose, url.Values{
+		trackTemplateParam: {e.Template},
+		trackMessageParam:  {e.MessageID},
+	})
+
+	return err
+}
+
+// Click is a recorded click on a tracked link
+type Click struct {
+	// URL is the original link that was clicked
+	URL string
+	// Template is the name of the template of the email the link was in
+	Template string
+	// MessageID identifies the email the link was in
+	MessageID string
+	// Time the link was clicked
+	Time time.Time
+	// UserAgent of the client that followed the link
+	UserAgent string
+	// RemoteAddr of the client that followed the link
+	RemoteAddr string
+}
+
+// ClickRecorder records clicks on tracked links
+type ClickRecorder interface {
+	// RecordClick records the click, an error is logged and does not stop the redirect
+	RecordClick(ctx context.Context, click Click) error
+}
+
+// ClickRecorderFunc is an adapter to use a function as a ClickRecorder
+type ClickRecorderFunc func(ctx context.Context, click Click) error
+
+// RecordClick calls f(ctx, click)
+func (f ClickRecorderFunc) RecordClick(ctx context.Context, click Click) error {
+	return f(ctx, click)
+}
+
+// newMessageID returns a random ID for a message
+func newMessageID() string {
+	b := make([]byte, messageIDBytes)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// trackClicks rewrites the links in the html to redirect through the click URL, mailto links and the
+// open pixel are kept
+func (e EmailData) trackClicks(body string) string {
+	if !e.Tracking.Clicks || !e.tracked() {
+		return body
+	}
+
+	return rewriteLinks(body, func(link string) string {
+		if !trackableURL(link) {
+			return link
+		}
+
+		tracked, err := e.Tracking.signedURL(e.Tracking.ClickURL, trackClickPurpose, url.Values{
+			trackURLParam:      {link},
+			trackTemplateParam: {e.Template},
+			trackMessageParam:  {e.MessageID},
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("template", e.Template).Msg("could not track link")
+
+			return link
+		}
+
+		return tracked
+	})
+}
+
+// signedURL returns the tracking URL with the values, the purpose and their signature added to its query,
+// the values replace parameters of the tracking URL with the same name
+func (t TrackingConfig) signedURL(trackingURL, purpose string, values url.Values) (string, error) {
+	u, err := url.Parse(trackingURL)
+	if err != nil {
+		return "", err
+	}
+
+	values.Set(trackPurposeParam, purpose)
+
+	query := u.Query()
+
+	for k, v := range values {
+		query[k] = v
+	}
+
+	query.Set(trackSigParam, signature([]byte(t.Secret), values))
+
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// verify checks the signature and purpose of the tracking parameters and returns them, the other parameters
+// of the tracking URL are ignored
+func (t TrackingConfig) verify(query url.Values, purpose string) (url.Values, bool) {
+	sig := query.Get(trackSigParam)
+
+	values := url.Values{}
+
+	for _, k := range trackParams {
+		if v, ok := query[k]; ok {
+			values[k] = v
+		}
+	}
+
+	return values, sig != "" && values.Get(trackPurposeParam) == purpose && hmac.Equal([]byte(sig), []byte(signature([]byte(t.Secret), values)))
+}
+
+// ClickHandler verifies the signature of a tracked link, records the click and redirects to the original link
+type ClickHandler struct {
+	config   TrackingConfig
+	recorder ClickRecorder
+}
+
+// NewClickHandler returns a handler for the tracking URLs, serve it on the configured ClickURL
+func NewClickHandler(config TrackingConfig, recorder ClickRecorder) *ClickHandler {
+	return &ClickHandler{config: config, recorder: recorder}
+}
+
+// ServeHTTP records the click and redirects, links with an invalid signature or a destination that is
+// not an absolute http or https URL are rejected
+func (h *ClickHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	values, ok := h.config.verify(r.URL.Query(), trackClickPurpose)
+	if !ok {
+		http.Error(w, ErrInvalidSignature.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !trackableURL(values.Get(trackURLParam)) {
+		http.Error(w, ErrInvalidTrackedURL.Error(), http.StatusBadRequest)
+		return
+	}
+
+	click := Click{
+		URL:        values.Get(trackURLParam),
+		Template:   values.Get(trackTemplateParam),
+		MessageID:  values.Get(trackMessageParam),
+		Time:       time.Now(),
+		UserAgent:  r.UserAgent(),
+		RemoteAddr: r.RemoteAddr,
+	}
+
+	if h.recorder != nil {
+		if err := h.recorder.RecordClick(r.Context(), click); err != nil {
+			log.Error().Err(err).Str("template", click.Template).Msg("could not record click")
+		}
+	}
+
+	http.Redirect(w, r, click.URL, http.StatusFound)
+}
+
+// trackableURL reports whether the link is an absolute http or https URL, the only links that are tracked
+func trackableURL(link string) bool {
+	u, err := url.Parse(link)
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// pixel is a transparent 1x1 gif
+var pixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// Open is a recorded open of a tracked email
+type Open struct {
+	// Template is the name of the template of the email
+	Template string
+	// MessageID identifies the email
+	MessageID string
+	// Time the email was opened
+	Time time.Time
+	// UserAgent of the client that loaded the pixel, often a mail proxy
+	UserAgent string
+	// RemoteAddr of the client that loaded the pixel
+	RemoteAddr string
+}
+
+// OpenRecorder records opens of tracked emails
+type OpenRecorder interface {
+	// RecordOpen records the open, an error is logged and does not stop the pixel from being served
+	RecordOpen(ctx context.Context, open Open) error
+}
+
+// OpenRecorderFunc is an adapter to use a function as an OpenRecorder
+type OpenRecorderFunc func(ctx context.Context, open Open) error
+
+// RecordOpen calls f(ctx, open)
+func (f OpenRecorderFunc) RecordOpen(ctx context.Context, open Open) error {
+	return f(ctx, open)
+}
+
+// OpenHandler serves the open tracking pixel and records opens of pixels with a valid signature
+type OpenHandler struct {
+	config   TrackingConfig
+	recorder OpenRecorder
+}
+
+// NewOpenHandler returns a handler for the open tracking pixel, serve it on the configured OpenURL
+func NewOpenHandler(config TrackingConfig, recorder OpenRecorder) *OpenHandler {
+	return &OpenHandler{config: config, recorder: recorder}
+}
+
+// ServeHTTP serves the pixel, the open is only recorded when the signature is valid so the image
+// never shows as broken in the email
+func (h *OpenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if values, ok := h.config.verify(r.URL.Query(), trackOpenPurpose); ok && h.recorder != nil {
+		open := Open{
+			Template:   values.Get(trackTemplateParam),
+			MessageID:  values.Get(trackMessageParam),
+			Time:       time.Now(),
+			UserAgent:  r.UserAgent(),
+			RemoteAddr: r.RemoteAddr,
+		}
+
+		if err := h.recorder.RecordOpen(r.Context(), open); err != nil {
+			log.Error().Err(err).Str("template", open.Template).Msg("could not record open")
+		}
+	}
+
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+	w.Header().Set("Content-Length", strconv.Itoa(len(pixel)))
+
+	_, _ = w.Write(pixel)
+}
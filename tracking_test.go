@@ -0,0 +1,294 @@
+package emailtemplates
+
+import (
+	"context"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theopenlane/newman"
+)
+
+// trackingData returns the data of a verify email with click tracking enabled
+func trackingData(excludeTemplates ...string) VerifyEmailData {
+	data := VerifyEmailData{
+		EmailData: EmailData{
+			Config: Config{
+				CompanyName:  "Test Company",
+				FromEmail:    "no-reply@example.com",
+				SupportEmail: "support@example.com",
+				URLS: URLConfig{
+					Root:    "https://www.example.com",
+					Product: "https://console.example.com",
+					Verify:  "https://console.example.com/verify?token=abc",
+				},
+			},
+			Recipient: Recipient{Email: "test@example.com"},
+		},
+	}
+
+	WithClickTracking("https://click.example.com/c", "secret", excludeTemplates...)(&data.Config)
+
+	return data
+}
+
+// tagValue returns the value of the message tag
+func tagValue(msg *newman.EmailMessage, name string) string {
+	for _, tag := range msg.Tags {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+
+	return ""
+}
+
+func TestClickTracking(t *testing.T) {
+	data := trackingData()
+
+	email, err := verify(data)
+	require.NoError(t, err)
+
+	messageID := tagValue(email, MessageIDTag)
+	require.NotEmpty(t, messageID)
+
+	links := extractLinks(email.HTML)
+	require.NotEmpty(t, links)
+
+	var tracked string
+
+	for _, link := range links {
+		if strings.HasPrefix(link, "mailto:") {
+			continue
+		}
+
+		require.True(t, strings.HasPrefix(link, "https://click.example.com/c?"), link)
+
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+
+		if u.Query().Get("u") == "https://console.example.com/verify?token=abc" {
+			tracked = link
+		}
+	}
+
+	require.NotEmpty(t, tracked, "the action link should be tracked")
+	assert.Contains(t, email.HTML, `href="mailto:support@example.com"`)
+
+	var recorded []Click
+
+	handler := NewClickHandler(data.Tracking, ClickRecorderFunc(func(_ context.Context, click Click) error {
+		recorded = append(recorded, click)
+
+		return nil
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tracked, nil))
+
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "https://console.example.com/verify?token=abc", rec.Header().Get("Location"))
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "verify_email", recorded[0].Template)
+	assert.Equal(t, messageID, recorded[0].MessageID)
+	assert.Equal(t, "https://console.example.com/verify?token=abc", recorded[0].URL)
+
+	// changing the destination invalidates the signature
+	u, err := url.Parse(tracked)
+	require.NoError(t, err)
+
+	query := u.Query()
+	query.Set("u", "https://evil.example.net")
+	u.RawQuery = query.Encode()
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.String(), nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Len(t, recorded, 1)
+}
+
+func TestClickHandlerRejects(t *testing.T) {
+	config := TrackingConfig{
+		Clicks:   true,
+		ClickURL: "https://click.example.com/c",
+		Opens:    true,
+		OpenURL:  "https://click.example.com/o.gif",
+		Secret:   "secret",
+	}
+
+	var recorded []Click
+
+	handler := NewClickHandler(config, ClickRecorderFunc(func(_ context.Context, click Click) error {
+		recorded = append(recorded, click)
+
+		return nil
+	}))
+
+	pixelURL, err := config.signedURL(config.OpenURL, trackOpenPurpose, url.Values{
+		trackTemplateParam: {"verify_email"},
+		trackMessageParam:  {"message-1"},
+	})
+	require.NoError(t, err)
+
+	// signed urls the handler must reject, by name
+	tests := map[string]string{"open pixel url": pixelURL}
+
+	for _, dest := range []string{"", "/relative", "javascript:alert(1)", "https://"} {
+		signed, err := config.signedURL(config.ClickURL, trackClickPurpose, url.Values{
+			trackURLParam:      {dest},
+			trackTemplateParam: {"verify_email"},
+			trackMessageParam:  {"message-1"},
+		})
+		require.NoError(t, err)
+
+		tests["destination "+dest] = signed
+	}
+
+	for name, signed := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, signed, nil))
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Empty(t, rec.Header().Get("Location"))
+		})
+	}
+
+	assert.Empty(t, recorded)
+}
+
+func TestTrackingURLQuery(t *testing.T) {
+	config := TrackingConfig{Clicks: true, ClickURL: "https://t.example.com/c?tenant=x", Secret: "secret"}
+
+	tracked, err := config.signedURL(config.ClickURL, trackClickPurpose, url.Values{
+		trackURLParam:      {"https://www.example.com"},
+		trackTemplateParam: {"welcome"},
+		trackMessageParam:  {"message-1"},
+	})
+	require.NoError(t, err)
+
+	u, err := url.Parse(tracked)
+	require.NoError(t, err)
+	assert.Equal(t, "x", u.Query().Get("tenant"))
+
+	var recorded []Click
+
+	handler := NewClickHandler(config, ClickRecorderFunc(func(_ context.Context, click Click) error {
+		recorded = append(recorded, click)
+
+		return nil
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tracked, nil))
+
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "https://www.example.com", rec.Header().Get("Location"))
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "message-1", recorded[0].MessageID)
+}
+
+func TestClickTrackingOptOut(t *testing.T) {
+	data := trackingData("verify_email")
+	data.MessageID = "message-1"
+
+	email, err := verify(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "message-1", tagValue(email, MessageIDTag))
+	assert.NotContains(t, email.HTML, "click.example.com")
+	assert.Contains(t, email.HTML, `href="https://console.example.com/verify?token=abc"`)
+}
+
+func TestTrackingValidate(t *testing.T) {
+	require.NoError(t, TrackingConfig{}.validate())
+	require.Error(t, TrackingConfig{Clicks: true, Secret: "secret"}.validate())
+	require.Error(t, TrackingConfig{Clicks: true, ClickURL: "https://click.example.com/c"}.validate())
+	require.NoError(t, TrackingConfig{Clicks: true, ClickURL: "https://click.example.com/c", Secret: "secret"}.validate())
+	require.Error(t, TrackingConfig{Opens: true, Secret: "secret"}.validate())
+	require.Error(t, TrackingConfig{Opens: true, OpenURL: "https://click.example.com/o.gif"}.validate())
+	require.NoError(t, TrackingConfig{Opens: true, OpenURL: "https://click.example.com/o.gif", Secret: "secret"}.validate())
+}
+
+func TestOpenTracking(t *testing.T) {
+	data := trackingData()
+	WithOpenTracking("https://click.example.com/o.gif", "secret")(&data.Config)
+
+	email, err := verify(data)
+	require.NoError(t, err)
+
+	messageID := tagValue(email, MessageIDTag)
+	require.NotEmpty(t, messageID)
+
+	start := strings.Index(email.HTML, `<img src="https://click.example.com/o.gif?`)
+	require.NotEqual(t, -1, start, "the pixel should be added to the html")
+	assert.Less(t, start, strings.Index(email.HTML, "</body>"))
+
+	src := email.HTML[start+len(`<img src="`):]
+	src = html.UnescapeString(src[:strings.Index(src, `"`)])
+
+	var recorded []Open
+
+	handler := NewOpenHandler(data.Tracking, OpenRecorderFunc(func(_ context.Context, open Open) error {
+		recorded = append(recorded, open)
+
+		return nil
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, src, nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/gif", rec.Header().Get("Content-Type"))
+	assert.Equal(t, pixel, rec.Body.Bytes())
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "verify_email", recorded[0].Template)
+	assert.Equal(t, messageID, recorded[0].MessageID)
+
+	// a pixel with an invalid signature is served but not recorded
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://click.example.com/o.gif?t=verify_email&m=forged&s=bad", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, pixel, rec.Body.Bytes())
+	assert.Len(t, recorded, 1)
+
+	// a signed click url is not recorded as an open
+	clickURL, err := data.Tracking.signedURL("https://click.example.com/o.gif", trackClickPurpose, url.Values{
+		trackURLParam:      {"https://www.example.com"},
+		trackTemplateParam: {"verify_email"},
+		trackMessageParam:  {messageID},
+	})
+	require.NoError(t, err)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, clickURL, nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, recorded, 1)
+}
+
+func TestOpenTrackingOptOut(t *testing.T) {
+	data := trackingData()
+	WithOpenTracking("https://click.example.com/o.gif", "secret", "verify_email")(&data.Config)
+
+	email, err := verify(data)
+	require.NoError(t, err)
+	assert.NotContains(t, email.HTML, "o.gif")
+
+	data = trackingData()
+	WithOpenTracking("https://click.example.com/o.gif", "secret")(&data.Config)
+	data.Recipient.DoNotTrack = true
+
+	email, err = verify(data)
+	require.NoError(t, err)
+	assert.NotContains(t, email.HTML, "o.gif")
+	assert.NotContains(t, email.HTML, "click.example.com")
+	assert.Empty(t, tagValue(email, MessageIDTag))
+}
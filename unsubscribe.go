@@ -0,0 +1,106 @@
+package emailtemplates
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// List-Unsubscribe headers added to the messages, see RFC 2369 and RFC 8058
+const (
+	ListUnsubscribeHeader     = "List-Unsubscribe"
+	ListUnsubscribePostHeader = "List-Unsubscribe-Post"
+
+	// listUnsubscribeOneClick is the value of the List-Unsubscribe-Post header for one-click unsubscribes
+	listUnsubscribeOneClick = "List-Unsubscribe=One-Click"
+
+	// unsubscribeTokenParts is the number of dot separated parts of an unsubscribe token
+	unsubscribeTokenParts = 3
+)
+
+// UnsubscribeToken returns a token for the recipient signed with the active key. The token does not
+// expire so the links keep working in old emails, and carries the address encoded rather than in the
+// clear
+func (s SignedURLConfig) UnsubscribeToken(email string) (string, error) {
+	secret, err := s.key(s.KeyID)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(email))
+
+	return encoded + "." + s.KeyID + "." + unsubscribeSignature(secret, email), nil
+}
+
+// VerifyUnsubscribeToken verifies the signature of an unsubscribe token and returns the recipient it
+// was issued for. The unsubscribe endpoint receives the token in the token query parameter, for both
+// the link in the footer and the one-click POST of RFC 8058
+func VerifyUnsubscribeToken(cfg SignedURLConfig, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != unsubscribeTokenParts {
+		return "", ErrInvalidSignature
+	}
+
+	email, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	secret, err := cfg.key(parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(unsubscribeSignature(secret, string(email)))) {
+		return "", ErrInvalidSignature
+	}
+
+	return string(email), nil
+}
+
+// unsubscribeSignature signs the email for the unsubscribe purpose so the signature of another signed
+// URL cannot be used as an unsubscribe token
+func unsubscribeSignature(secret []byte, email string) string {
+	return signature(secret, url.Values{
+		signedEmailParam:   {email},
+		signedPurposeParam: {string(PurposeUnsubscribe)},
+	})
+}
+
+// prepareUnsubscribe sets the unsubscribe URL of the recipient before the email is rendered, emails
+// of categories that cannot be unsubscribed from and emails sent when no unsubscribe URL or signing
+// key is configured have none
+func (e *EmailData) prepareUnsubscribe() error {
+	if !e.Category.Unsubscribable() {
+		e.UnsubscribeURL = ""
+
+		return nil
+	}
+
+	if e.UnsubscribeURL != "" || e.URLS.Unsubscribe == "" || e.SignedURLs.KeyID == "" {
+		return nil
+	}
+
+	token, err := e.SignedURLs.UnsubscribeToken(e.Recipient.Email)
+	if err != nil {
+		return err
+	}
+
+	e.UnsubscribeURL, err = addTokenToURL(e.URLS.Unsubscribe, token)
+
+	return err
+}
+
+// unsubscribeHeaders returns the List-Unsubscribe headers for the unsubscribe URL, the map is empty
+// when there is none
+func (e EmailData) unsubscribeHeaders() map[string]string {
+	if e.UnsubscribeURL == "" {
+		return map[string]string{}
+	}
+
+	return map[string]string{
+		ListUnsubscribeHeader:     "<" + e.UnsubscribeURL + ">",
+		ListUnsubscribePostHeader: listUnsubscribeOneClick,
+	}
+}
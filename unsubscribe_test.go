@@ -0,0 +1,97 @@
+package emailtemplates
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsubscribeToken(t *testing.T) {
+	cfg := SignedURLConfig{KeyID: "v1", Keys: map[string]string{"v1": "secret"}}
+
+	token, err := cfg.UnsubscribeToken("test@example.com")
+	require.NoError(t, err)
+	assert.NotContains(t, token, "test@example.com")
+
+	email, err := VerifyUnsubscribeToken(cfg, token)
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", email)
+
+	// tokens signed with a retired key still verify
+	cfg.KeyID = "v2"
+	cfg.Keys["v2"] = "rotated"
+
+	email, err = VerifyUnsubscribeToken(cfg, token)
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", email)
+
+	// the address cannot be swapped for another recipient
+	forged, err := (SignedURLConfig{KeyID: "v1", Keys: map[string]string{"v1": "other"}}).UnsubscribeToken("victim@example.com")
+	require.NoError(t, err)
+
+	_, err = VerifyUnsubscribeToken(cfg, forged)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = VerifyUnsubscribeToken(cfg, "not-a-token")
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = VerifyUnsubscribeToken(SignedURLConfig{}, token)
+	require.ErrorIs(t, err, ErrSigningKeyNotFound)
+}
+
+func TestUnsubscribeInEmail(t *testing.T) {
+	data := SubscriberEmailData{
+		EmailData: EmailData{
+			Config: Config{
+				CompanyName: "Test Company",
+				FromEmail:   "no-reply@example.com",
+				URLS: URLConfig{
+					Root:             "https://www.example.com",
+					Product:          "https://console.example.com",
+					VerifySubscriber: "https://console.example.com/subscriber-verify?token=abc",
+					Unsubscribe:      "https://console.example.com/unsubscribe",
+				},
+				SignedURLs: SignedURLConfig{KeyID: "v1", Keys: map[string]string{"v1": "secret"}},
+			},
+			Recipient: Recipient{Email: "test@example.com"},
+		},
+		OrganizationName: "Meow Inc.",
+	}
+
+	email, err := subscribe(data)
+	require.NoError(t, err)
+
+	header := email.Headers[ListUnsubscribeHeader]
+	require.NotEmpty(t, header)
+	assert.Equal(t, "List-Unsubscribe=One-Click", email.Headers[ListUnsubscribePostHeader])
+
+	unsubscribeURL := header[1 : len(header)-1]
+	assert.Contains(t, email.Text, "Unsubscribe "+unsubscribeURL)
+	assert.Contains(t, email.HTML, `>unsubscribe</a>`)
+	assert.NotContains(t, email.Text, "?email=")
+
+	u, err := url.Parse(unsubscribeURL)
+	require.NoError(t, err)
+
+	recipient, err := VerifyUnsubscribeToken(data.SignedURLs, u.Query().Get("token"))
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", recipient)
+
+	// without a signing key the email is sent without an unsubscribe link
+	data.SignedURLs = SignedURLConfig{}
+
+	email, err = subscribe(data)
+	require.NoError(t, err)
+	assert.Empty(t, email.Headers)
+	assert.NotContains(t, email.Text, "Unsubscribe")
+
+	// a key without an active key id, e.g. only a retired key kept for verification, does not fail the email
+	WithSigningKey("v0", "secret")(&data.Config)
+
+	email, err = subscribe(data)
+	require.NoError(t, err)
+	assert.Empty(t, email.Headers)
+	assert.NotContains(t, email.Text, "Unsubscribe")
+}
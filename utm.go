@@ -0,0 +1,189 @@
+package emailtemplates
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// UTM query parameters added to the links
+const (
+	utmSourceParam   = "utm_source"
+	utmMediumParam   = "utm_medium"
+	utmCampaignParam = "utm_campaign"
+
+	// defaultUTMSource and defaultUTMMedium are used when the parameters of an email do not set them
+	defaultUTMSource = "email"
+	defaultUTMMedium = "email"
+)
+
+// UTMConfig configures the campaign parameters added to the links of the emails for attribution
+type UTMConfig struct {
+	// Templates are the emails the parameters are added to, keyed by template name, e.g. welcome or subscribe
+	Templates map[string]UTMParams `koanf:"templates" json:"templates"`
+	// Domains are the domains, including their subdomains, whose links are tagged. When empty the
+	// domains of the root, product and docs URLs are used
+	Domains []string `koanf:"domains" json:"domains"`
+	// IncludeTokenLinks also tags links carrying a token or signature, which are skipped by default
+	IncludeTokenLinks bool `koanf:"includetokenlinks" json:"includetokenlinks" default:"false"`
+}
+
+// UTMParams are the campaign parameters added to the links of an email
+type UTMParams struct {
+	// Source is the utm_source, defaults to email
+	Source string `koanf:"source" json:"source" default:"email"`
+	// Medium is the utm_medium, defaults to email
+	Medium string `koanf:"medium" json:"medium" default:"email"`
+	// Campaign is the utm_campaign, defaults to the template name
+	Campaign string `koanf:"campaign" json:"campaign" default:""`
+	// Params are additional query parameters, e.g. utm_content
+	Params map[string]string `koanf:"params" json:"params"`
+}
+
+// values returns the query parameters for the template, in a stable order
+func (p UTMParams) values(template string) [][2]string {
+	values := [][2]string{
+		{utmSourceParam, valueOr(p.Source, defaultUTMSource)},
+		{utmMediumParam, valueOr(p.Medium, defaultUTMMedium)},
+		{utmCampaignParam, valueOr(p.Campaign, template)},
+	}
+
+	keys := make([]string, 0, len(p.Params))
+	for k := range p.Params {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		values = append(values, [2]string{k, p.Params[k]})
+	}
+
+	return values
+}
+
+// valueOr returns the value, or the fallback when it is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+// addUTMParams adds the campaign parameters configured for the template to the links of the body on
+// the allowed domains with the rewrite of the html or text body, existing query parameters are kept
+// and never overwritten
+func (e EmailData) addUTMParams(body string, rewrite func(string, func(string) string) string) string {
+	params, ok := e.UTM.Templates[e.Template]
+	if !ok {
+		return body
+	}
+
+	domains := e.UTM.Domains
+	if len(domains) == 0 {
+		domains = e.URLS.domains()
+	}
+
+	values := params.values(e.Template)
+
+	return rewrite(body, func(link string) string {
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || !domainAllowed(u.Hostname(), domains) {
+			return link
+		}
+
+		query := u.Query()
+
+		if !e.UTM.IncludeTokenLinks && e.actionLink(link, u) {
+			return link
+		}
+
+		// new parameters are appended so the existing ones keep their order
+		added := []string{}
+
+		for _, v := range values {
+			if !query.Has(v[0]) {
+				added = append(added, url.QueryEscape(v[0])+"="+url.QueryEscape(v[1]))
+			}
+		}
+
+		if len(added) == 0 {
+			return link
+		}
+
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+
+		u.RawQuery += strings.Join(added, "&")
+
+		return u.String()
+	})
+}
+
+// addActionLinks records the action links of the email, empty links are skipped
+func (e *EmailData) addActionLinks(links ...string) {
+	for _, link := range links {
+		if link != "" {
+			e.actionLinks = append(e.actionLinks, link)
+		}
+	}
+}
+
+// actionLink reports whether the link is an action link carrying a token or signature, one generated by
+// the builders or on the path of a configured action URL, including a token placeholder in the path
+func (e EmailData) actionLink(link string, u *url.URL) bool {
+	if slices.Contains(e.actionLinks, link) {
+		return true
+	}
+
+	target := strings.TrimSuffix(u.Scheme+"://"+u.Host+u.EscapedPath(), "/")
+
+	for _, action := range e.URLS.actionURLs() {
+		base, placeholder := action, false
+		if i := strings.Index(base, TokenPlaceholder); i >= 0 {
+			base, placeholder = base[:i], true
+		}
+
+		inPath := !strings.ContainsAny(base, "?#")
+		if i := strings.IndexAny(base, "?#"); i >= 0 {
+			base = base[:i]
+		}
+
+		base = strings.TrimSuffix(base, "/")
+
+		if target == base || (placeholder && inPath && strings.HasPrefix(target, base+"/")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// actionURLs returns the configured URLs the action links of the built in emails are made from
+func (u URLConfig) actionURLs() []string {
+	urls := []string{}
+
+	for _, raw := range []string{u.Verify, u.Invite, u.PasswordReset, u.VerifySubscriber, u.VerifyBilling, u.Questionnaire, u.Unsubscribe} {
+		if raw != "" {
+			urls = append(urls, raw)
+		}
+	}
+
+	return urls
+}
+
+// domains returns the hosts of the root, product and docs URLs
+func (u URLConfig) domains() []string {
+	domains := []string{}
+
+	for _, raw := range []string{u.Root, u.Product, u.Docs} {
+		if parsed, err := url.Parse(raw); err == nil && parsed.Hostname() != "" {
+			domains = append(domains, parsed.Hostname())
+		}
+	}
+
+	return domains
+}
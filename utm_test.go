@@ -0,0 +1,130 @@
+package emailtemplates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	body := `<a href="https://example.com/a?x=1&amp;y=2">A</a> <a href='mailto:me@example.com'>B</a>`
+
+	unchanged := rewriteLinks(body, func(link string) string { return link })
+	assert.Equal(t, body, unchanged)
+
+	rewritten := rewriteLinks(body, func(link string) string {
+		if link == "https://example.com/a?x=1&y=2" {
+			return link + "&z=3"
+		}
+
+		return link
+	})
+	assert.Equal(t, `<a href="https://example.com/a?x=1&amp;y=2&amp;z=3">A</a> <a href='mailto:me@example.com'>B</a>`, rewritten)
+}
+
+func TestAddUTMParams(t *testing.T) {
+	body := `<a href="https://www.example.com/pricing?plan=pro">Pricing</a>
+<a href="https://docs.example.com">Docs</a>
+<a href="https://other.com/page">Other</a>
+<a href="mailto:support@example.com">Support</a>
+<a href="https://console.example.com/verify?token=abc">Verify</a>
+<a href="https://console.example.com/reset#token=abc">Reset</a>
+<a href="https://www.example.com/blog?utm_campaign=spring">Blog</a>
+<a href="https://console.example.com/invite/abc">Invite</a>
+<a href="https://console.example.com/nda?signed=abc">NDA</a>`
+
+	e := EmailData{
+		Config: Config{
+			URLS: URLConfig{
+				Root:          "https://www.example.com",
+				Docs:          "https://docs.example.com",
+				Verify:        "https://console.example.com/verify?token={token}",
+				PasswordReset: "https://console.example.com/reset#token={token}",
+				Invite:        "https://console.example.com/invite/{token}",
+			},
+		},
+		Template: "welcome",
+	}
+
+	e.addActionLinks("https://console.example.com/nda?signed=abc")
+
+	// emails without parameters are not changed
+	assert.Equal(t, body, e.addUTMParams(body, rewriteLinks))
+
+	WithUTM("welcome", UTMParams{Params: map[string]string{"utm_content": "footer"}})(&e.Config)
+
+	tagged := e.addUTMParams(body, rewriteLinks)
+	assert.Contains(t, tagged, `href="https://www.example.com/pricing?plan=pro&amp;utm_source=email&amp;utm_medium=email&amp;utm_campaign=welcome&amp;utm_content=footer"`)
+	assert.Contains(t, tagged, `href="https://docs.example.com?utm_source=email&amp;utm_medium=email&amp;utm_campaign=welcome&amp;utm_content=footer"`)
+	assert.Contains(t, tagged, `href="https://other.com/page"`)
+	assert.Contains(t, tagged, `href="mailto:support@example.com"`)
+	assert.Contains(t, tagged, `href="https://console.example.com/verify?token=abc"`)
+	assert.Contains(t, tagged, `href="https://console.example.com/reset#token=abc"`)
+	assert.Contains(t, tagged, `href="https://console.example.com/invite/abc"`)
+	assert.Contains(t, tagged, `href="https://console.example.com/nda?signed=abc"`)
+	assert.Contains(t, tagged, `href="https://www.example.com/blog?utm_campaign=spring&amp;utm_source=email&amp;utm_medium=email&amp;utm_content=footer"`)
+
+	// explicitly allowed domains replace the configured ones and token links can be included
+	e.UTM.Domains = []string{"example.com"}
+	e.UTM.IncludeTokenLinks = true
+
+	tagged = e.addUTMParams(body, rewriteLinks)
+	assert.Contains(t, tagged, `href="https://console.example.com/verify?token=abc&amp;utm_source=email`)
+	assert.Contains(t, tagged, `href="https://console.example.com/invite/abc?utm_source=email`)
+	assert.Contains(t, tagged, `href="https://other.com/page"`)
+	assert.Contains(t, tagged, `href="mailto:support@example.com"`)
+}
+
+func TestAddUTMParamsText(t *testing.T) {
+	body := `Read the docs at https://docs.example.com/start.
+Verify your email: https://console.example.com/verify/abc
+Pricing (https://www.example.com/pricing?plan=pro)`
+
+	e := EmailData{
+		Config: Config{
+			URLS: URLConfig{
+				Root:   "https://www.example.com",
+				Docs:   "https://docs.example.com",
+				Verify: "https://console.example.com/verify/{token}",
+			},
+			UTM: UTMConfig{
+				Domains:   []string{"example.com"},
+				Templates: map[string]UTMParams{"welcome": {}},
+			},
+		},
+		Template: "welcome",
+	}
+
+	tagged := e.addUTMParams(body, rewriteTextLinks)
+	assert.Contains(t, tagged, "https://docs.example.com/start?utm_source=email&utm_medium=email&utm_campaign=welcome.\n")
+	assert.Contains(t, tagged, "https://console.example.com/verify/abc\n")
+	assert.Contains(t, tagged, "(https://www.example.com/pricing?plan=pro&utm_source=email&utm_medium=email&utm_campaign=welcome)")
+}
+
+func TestUTMInEmail(t *testing.T) {
+	data := SubscriberEmailData{
+		EmailData: EmailData{
+			Config: Config{
+				CompanyName: "Test Company",
+				FromEmail:   "no-reply@example.com",
+				URLS: URLConfig{
+					Root:             "https://www.example.com",
+					Product:          "https://console.example.com",
+					VerifySubscriber: "https://console.example.com/subscriber-verify?token=abc",
+				},
+			},
+			Recipient: Recipient{Email: "test@example.com"},
+		},
+		OrganizationName: "Meow Inc.",
+	}
+
+	WithUTM("subscribe", UTMParams{Campaign: "newsletter"})(&data.Config)
+
+	email, err := subscribe(data)
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, `href="https://www.example.com/legal/privacy?utm_source=email&amp;utm_medium=email&amp;utm_campaign=newsletter"`)
+	assert.Contains(t, email.HTML, `href="https://console.example.com/subscriber-verify?token=abc"`)
+	assert.Contains(t, email.Text, "https://www.example.com/legal/privacy?utm_source=email&utm_medium=email&utm_campaign=newsletter")
+	assert.NotContains(t, email.Text, "subscriber-verify?token=abc&utm_source")
+}
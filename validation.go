@@ -0,0 +1,187 @@
+package emailtemplates
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/mail"
+	"net/url"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// logoExtensions are the image types accepted for the logo URL
+var logoExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp"}
+
+// templateURLs are the action URLs of the built in templates, required when an email of the template is built
+var templateURLs = map[string][]string{
+	"verify_email":           {"URLS.Verify"},
+	"invite":                 {"URLS.Invite"},
+	"password_reset_request": {"URLS.PasswordReset"},
+	"subscribe":              {"URLS.VerifySubscriber"},
+	"verify_billing":         {"URLS.VerifyBilling"},
+}
+
+// validator collects the problems with the fields of the config
+type validator struct {
+	errs []*FieldError
+}
+
+// add records the problem with the field
+func (v *validator) add(field string, err error) {
+	v.errs = append(v.errs, &FieldError{Field: field, Err: err})
+}
+
+// nested records the problems of a nested config under the field, prefixing the paths of its field errors
+func (v *validator) nested(field string, err error) {
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		if err != nil {
+			v.add(field, err)
+		}
+
+		return
+	}
+
+	for _, fe := range verrs.Errors {
+		v.add(field+"."+fe.Field, fe.Err)
+	}
+}
+
+// required records a missing required field error when the value is empty
+func (v *validator) required(field, name, value string) bool {
+	if value == "" {
+		v.add(field, newMissingRequiredFieldError(name))
+
+		return false
+	}
+
+	return true
+}
+
+// email records an error when the value is set and is not an email address
+func (v *validator) email(field, value string) {
+	if value == "" {
+		return
+	}
+
+	if _, err := mail.ParseAddress(value); err != nil {
+		v.add(field, fmt.Errorf("%w %q", ErrInvalidAddress, value))
+	}
+}
+
+// url records an error when the value is set and is not an absolute https URL
+func (v *validator) url(field, value string) {
+	if value == "" {
+		return
+	}
+
+	if _, err := httpsURL(value); err != nil {
+		v.add(field, fmt.Errorf("%w: %q", ErrInvalidURL, value))
+	}
+}
+
+// err returns the collected problems as ValidationErrors, or nil when there are none
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+
+	return &ValidationErrors{Errors: v.errs}
+}
+
+// httpsURL parses the value as an absolute https URL
+func httpsURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "https" || u.Host == "" {
+		return nil, ErrInvalidURL
+	}
+
+	return u, nil
+}
+
+// validateURLs records an error for every set field of the URL config that is not an absolute https URL
+func (v *validator) validateURLs(field string, urls URLConfig) {
+	value := reflect.ValueOf(urls)
+
+	for i := range value.NumField() {
+		if f := value.Type().Field(i); f.Type.Kind() == reflect.String {
+			v.url(field+"."+f.Name, value.Field(i).String())
+		}
+	}
+}
+
+// validateLogo records an error when the logo URL is set and is not an https URL to an image
+func (v *validator) validateLogo(field, value string) {
+	if value == "" {
+		return
+	}
+
+	u, err := httpsURL(value)
+	if err != nil || !slices.Contains(logoExtensions, strings.ToLower(path.Ext(u.Path))) {
+		v.add(field, fmt.Errorf("%w: %q", ErrInvalidLogoURL, value))
+	}
+}
+
+// validateSenders records an error for every invalid sender name and address or name of the sender profiles
+func (v *validator) validateSenders(c *Config) {
+	for _, name := range slices.Sorted(maps.Keys(c.SenderNames)) {
+		if _, err := template.New(name).Parse(c.SenderNames[name]); err != nil {
+			v.add("SenderNames."+name, err)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.SenderProfiles)) {
+		profile, field := c.SenderProfiles[name], "SenderProfiles."+name
+
+		v.email(field+".FromEmail", profile.FromEmail)
+		v.email(field+".ReturnPath", profile.ReturnPath)
+
+		for i, addr := range profile.ReplyTo {
+			v.email(fmt.Sprintf("%s.ReplyTo[%d]", field, i), addr)
+		}
+
+		if _, err := template.New(name).Parse(profile.FromName); err != nil {
+			v.add(field+".FromName", err)
+		}
+	}
+}
+
+// validateRecipients records an error for every invalid address of the template recipients
+func (v *validator) validateRecipients(field string, defaults map[string]RecipientDefaults) {
+	for _, name := range slices.Sorted(maps.Keys(defaults)) {
+		lists := []struct {
+			name      string
+			addresses []string
+		}{
+			{"CC", defaults[name].CC},
+			{"BCC", defaults[name].BCC},
+			{"ReplyTo", defaults[name].ReplyTo},
+		}
+
+		for _, list := range lists {
+			for i, addr := range list.addresses {
+				v.email(fmt.Sprintf("%s.%s.%s[%d]", field, name, list.name, i), addr)
+			}
+		}
+	}
+}
+
+// requireURLs returns ValidationErrors for the URLs of the config that are not set, by field path
+func (c Config) requireURLs(fields ...string) error {
+	v := validator{}
+	urls := reflect.ValueOf(c.URLS)
+
+	for _, field := range fields {
+		v.required(field, "url", urls.FieldByName(strings.TrimPrefix(field, "URLS.")).String())
+	}
+
+	return v.err()
+}
@@ -0,0 +1,100 @@
+package emailtemplates
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAggregatesErrors(t *testing.T) {
+	cfg := Config{
+		CompanyName:        "Test Company",
+		FromEmail:          "invalid",
+		SupportEmail:       "support",
+		QuestionnaireEmail: "questionnaire@example.com",
+		LogoURL:            "https://www.example.com/logo.html",
+		URLS: URLConfig{
+			Root:   "https://www.example.com",
+			Verify: "http://console.example.com/verify",
+			Invite: "/invite",
+		},
+		SenderProfiles: map[string]SenderProfile{
+			"billing": {FromEmail: "billing@example.com", ReplyTo: []string{"billing"}},
+		},
+		TemplateRecipients: map[string]RecipientDefaults{
+			"invite": {BCC: []string{"archive@example.com", "archive"}},
+		},
+		Tracking: TrackingConfig{Clicks: true, ClickURL: "click.example.com"},
+	}
+
+	err := cfg.Validate()
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	var verrs *ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+
+	assert.Equal(t, []string{
+		"CompanyAddress",
+		"FromEmail",
+		"SupportEmail",
+		"LogoURL",
+		"URLS.Verify",
+		"URLS.Invite",
+		"SenderProfiles.billing.ReplyTo[0]",
+		"TemplateRecipients.invite.BCC[1]",
+		"Tracking.ClickURL",
+		"Tracking.Secret",
+	}, verrs.Fields())
+
+	require.ErrorIs(t, err, ErrInvalidSenderEmail)
+	require.ErrorIs(t, err, ErrInvalidAddress)
+	require.ErrorIs(t, err, ErrInvalidURL)
+	require.ErrorIs(t, err, ErrInvalidLogoURL)
+
+	var missing *MissingRequiredFieldError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "company address", missing.RequiredField)
+
+	var fe *FieldError
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, "CompanyAddress", fe.Field)
+	assert.Contains(t, err.Error(), `URLS.Verify: must be an absolute https url: "http://console.example.com/verify"`)
+}
+
+func TestValidateValidConfig(t *testing.T) {
+	cfg := sampleConfig
+	require.NoError(t, cfg.Validate())
+
+	cfg.LogoURL = "https://cdn.example.com/assets/logo.SVG?v=2"
+	require.NoError(t, cfg.Validate())
+}
+
+func TestTemplateURLsRequired(t *testing.T) {
+	cfg := Config{
+		CompanyName: "Test Company",
+		FromEmail:   "no-reply@example.com",
+	}
+
+	_, err := cfg.NewInviteEmail(Recipient{Email: "test@example.com"}, InviteTemplateData{OrganizationName: "Meow Inc."}, "token")
+
+	var verrs *ValidationErrors
+	require.True(t, errors.As(err, &verrs))
+	assert.Equal(t, []string{"URLS.Invite"}, verrs.Fields())
+
+	// other emails do not require the invite url
+	_, err = cfg.NewWelcomeEmail(Recipient{Email: "test@example.com"})
+	require.NoError(t, err)
+
+	// the questionnaire url is only required without a full url
+	_, err = cfg.NewQuestionnaireAuthEmail(Recipient{Email: "test@example.com"}, "token", QuestionnaireAuthData{CompanyName: "Meow Inc."})
+	require.ErrorAs(t, err, &verrs)
+	assert.Equal(t, []string{"URLS.Questionnaire"}, verrs.Fields())
+
+	_, err = cfg.NewQuestionnaireAuthEmail(Recipient{Email: "test@example.com"}, "token", QuestionnaireAuthData{
+		CompanyName:              "Meow Inc.",
+		QuestionnaireAuthFullURL: "https://console.example.com/questionnaire?token=token",
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,4 @@
+// Package webhooks parses the delivery, bounce, complaint, open and click events posted by email
+// providers into a single DeliveryEvent, verifying their signatures where the provider supports it,
+// so bounces and complaints can be fed back into the suppression list
+package webhooks
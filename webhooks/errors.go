@@ -0,0 +1,16 @@
+package webhooks
+
+import "errors"
+
+var (
+	// ErrInvalidSignature is returned when a webhook request has a missing or invalid signature
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrTimestampOutOfRange is returned when a signed webhook request is too old or from the future
+	ErrTimestampOutOfRange = errors.New("webhook timestamp outside of the tolerance")
+	// ErrInvalidCertificateURL is returned when an SNS message is signed with a certificate not hosted by SNS
+	ErrInvalidCertificateURL = errors.New("invalid sns signing certificate url")
+	// ErrUnsupportedSignatureVersion is returned when an SNS message is not signed with SHA256, signature version 2
+	ErrUnsupportedSignatureVersion = errors.New("unsupported sns signature version")
+	// ErrInvalidPayload is returned when a webhook payload cannot be parsed
+	ErrInvalidPayload = errors.New("invalid webhook payload")
+)
@@ -0,0 +1,147 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Mailgun parses the json webhooks of Mailgun
+type Mailgun struct {
+	// SigningKey is the HTTP webhook signing key of the account, every request is rejected when empty
+	SigningKey string
+}
+
+// mailgunEvent is the payload of a Mailgun webhook
+type mailgunEvent struct {
+	Signature struct {
+		Timestamp string `json:"timestamp"`
+		Token     string `json:"token"`
+		Signature string `json:"signature"`
+	} `json:"signature"`
+	EventData struct {
+		Event     string  `json:"event"`
+		Severity  string  `json:"severity"`
+		Recipient string  `json:"recipient"`
+		Timestamp float64 `json:"timestamp"`
+		Reason    string  `json:"reason"`
+		URL       string  `json:"url"`
+		Message   struct {
+			Headers struct {
+				MessageID string `json:"message-id"`
+			} `json:"headers"`
+		} `json:"message"`
+		DeliveryStatus struct {
+			Description string `json:"description"`
+			Message     string `json:"message"`
+		} `json:"delivery-status"`
+		UserVariables map[string]any `json:"user-variables"`
+	} `json:"event-data"`
+}
+
+// mailgunEventTypes are the Mailgun events that are parsed, failed events are bounces
+var mailgunEventTypes = map[string]EventType{
+	"delivered":  EventDelivered,
+	"failed":     EventBounced,
+	"complained": EventComplained,
+	"opened":     EventOpened,
+	"clicked":    EventClicked,
+}
+
+// Name of the provider
+func (Mailgun) Name() string {
+	return "mailgun"
+}
+
+// Verify checks the signature of the timestamp and token in the payload
+func (p Mailgun) Verify(_ *http.Request, body []byte) error {
+	if p.SigningKey == "" {
+		return fmt.Errorf("%w: no webhook signing key configured", ErrInvalidSignature)
+	}
+
+	var e mailgunEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	seconds, err := strconv.ParseInt(e.Signature.Timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if err := checkTimestamp(time.Unix(seconds, 0)); err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.SigningKey))
+	mac.Write([]byte(e.Signature.Timestamp + e.Signature.Token))
+
+	if !hmac.Equal([]byte(e.Signature.Signature), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Parse returns the event in the payload
+func (p Mailgun) Parse(body []byte) ([]DeliveryEvent, error) {
+	var e mailgunEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	data := e.EventData
+
+	eventType, ok := mailgunEventTypes[data.Event]
+	if !ok {
+		return nil, nil
+	}
+
+	reason := data.DeliveryStatus.Description
+	if reason == "" {
+		reason = data.DeliveryStatus.Message
+	}
+
+	if reason == "" {
+		reason = data.Reason
+	}
+
+	seconds, fraction := math.Modf(data.Timestamp)
+
+	return []DeliveryEvent{{
+		Provider:  p.Name(),
+		Type:      eventType,
+		Email:     data.Recipient,
+		MessageID: data.Message.Headers.MessageID,
+		Timestamp: time.Unix(int64(seconds), int64(fraction*float64(time.Second))).UTC(),
+		Permanent: data.Severity == "permanent",
+		Reason:    reason,
+		URL:       data.URL,
+		Tags:      stringValues(data.UserVariables),
+	}}, nil
+}
+
+// stringValues returns the values of the map formatted as strings, nil for an empty map
+func stringValues(m map[string]any) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(m))
+
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		} else {
+			out[k] = fmt.Sprint(v)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signMailgun returns the fixture with its signature replaced by one made with the key at the time
+func signMailgun(t *testing.T, body []byte, key string, at time.Time) []byte {
+	t.Helper()
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(body, &payload))
+
+	sig := payload["signature"].(map[string]any)
+	sig["timestamp"] = strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(sig["timestamp"].(string) + sig["token"].(string)))
+	sig["signature"] = hex.EncodeToString(mac.Sum(nil))
+
+	signed, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func TestMailgunVerify(t *testing.T) {
+	body := fixture(t, "mailgun_failed.json")
+	provider := Mailgun{SigningKey: "mailgun-key"}
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/mailgun", nil)
+
+	require.NoError(t, provider.Verify(req, signMailgun(t, body, "mailgun-key", time.Now())))
+	require.ErrorIs(t, provider.Verify(req, signMailgun(t, body, "other-key", time.Now())), ErrInvalidSignature)
+	require.ErrorIs(t, provider.Verify(req, signMailgun(t, body, "mailgun-key", time.Now().Add(-time.Hour))), ErrTimestampOutOfRange)
+	require.ErrorIs(t, provider.Verify(req, body), ErrTimestampOutOfRange)
+	require.ErrorIs(t, Mailgun{}.Verify(req, signMailgun(t, body, "", time.Now())), ErrInvalidSignature)
+}
+
+func TestMailgunParse(t *testing.T) {
+	events, err := Mailgun{}.Parse(fixture(t, "mailgun_failed.json"))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	assert.Equal(t, "mailgun", events[0].Provider)
+	assert.Equal(t, EventBounced, events[0].Type)
+	assert.Equal(t, "bounced@example.com", events[0].Email)
+	assert.Equal(t, "20260314150926.1.ABCDEF@mail.theopenlane.io", events[0].MessageID)
+	assert.True(t, events[0].Permanent)
+	assert.Equal(t, "Not delivering to previously bounced address", events[0].Reason)
+	assert.Equal(t, int64(1773500966), events[0].Timestamp.Unix())
+	assert.Equal(t, "verify_email", events[0].Template())
+}
@@ -0,0 +1,116 @@
+package webhooks
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Postmark parses the webhooks of Postmark, which are not signed and are protected with basic auth
+// credentials in the webhook URL
+type Postmark struct {
+	// Username and Password are the basic auth credentials in the webhook URL, every request is rejected
+	// when both are empty
+	Username string
+	Password string
+}
+
+// postmarkEvent is the payload of a Postmark webhook
+type postmarkEvent struct {
+	RecordType   string            `json:"RecordType"`
+	MessageID    string            `json:"MessageID"`
+	Type         string            `json:"Type"`
+	Email        string            `json:"Email"`
+	Recipient    string            `json:"Recipient"`
+	Description  string            `json:"Description"`
+	Details      string            `json:"Details"`
+	OriginalLink string            `json:"OriginalLink"`
+	Metadata     map[string]string `json:"Metadata"`
+	Tag          string            `json:"Tag"`
+	BouncedAt    time.Time         `json:"BouncedAt"`
+	DeliveredAt  time.Time         `json:"DeliveredAt"`
+	ReceivedAt   time.Time         `json:"ReceivedAt"`
+}
+
+// postmarkEventTypes are the Postmark record types that are parsed
+var postmarkEventTypes = map[string]EventType{
+	"Delivery":      EventDelivered,
+	"Bounce":        EventBounced,
+	"SpamComplaint": EventComplained,
+	"Open":          EventOpened,
+	"Click":         EventClicked,
+}
+
+// postmarkPermanentBounces are the bounce types that will not succeed on retry
+var postmarkPermanentBounces = map[string]bool{
+	"HardBounce":          true,
+	"BadEmailAddress":     true,
+	"ManuallyDeactivated": true,
+}
+
+// Name of the provider
+func (Postmark) Name() string {
+	return "postmark"
+}
+
+// Verify checks the basic auth credentials of the request
+func (p Postmark) Verify(r *http.Request, _ []byte) error {
+	if p.Username == "" && p.Password == "" {
+		return fmt.Errorf("%w: no webhook credentials configured", ErrInvalidSignature)
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok ||
+		subtle.ConstantTimeCompare([]byte(username), []byte(p.Username)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(password), []byte(p.Password)) != 1 {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Parse returns the event in the payload, the tag of the message is returned as the tag tag along
+// with the metadata
+func (p Postmark) Parse(body []byte) ([]DeliveryEvent, error) {
+	var e postmarkEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	eventType, ok := postmarkEventTypes[e.RecordType]
+	if !ok {
+		return nil, nil
+	}
+
+	tags := e.Metadata
+	if e.Tag != "" {
+		if tags == nil {
+			tags = map[string]string{}
+		}
+
+		tags["tag"] = e.Tag
+	}
+
+	timestamp := e.ReceivedAt
+
+	switch eventType {
+	case EventBounced, EventComplained:
+		timestamp = e.BouncedAt
+	case EventDelivered:
+		timestamp = e.DeliveredAt
+	}
+
+	return []DeliveryEvent{{
+		Provider:  p.Name(),
+		Type:      eventType,
+		Email:     valueOr(e.Recipient, e.Email),
+		MessageID: e.MessageID,
+		Timestamp: timestamp,
+		Permanent: eventType == EventBounced && postmarkPermanentBounces[e.Type],
+		Reason:    valueOr(e.Description, e.Details),
+		URL:       e.OriginalLink,
+		Tags:      tags,
+	}}, nil
+}
@@ -0,0 +1,48 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostmarkVerify(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/postmark", nil)
+
+	require.ErrorIs(t, Postmark{}.Verify(req, nil), ErrInvalidSignature)
+	require.ErrorIs(t, Postmark{Username: "hook", Password: "secret"}.Verify(req, nil), ErrInvalidSignature)
+
+	req.SetBasicAuth("hook", "secret")
+	require.NoError(t, Postmark{Username: "hook", Password: "secret"}.Verify(req, nil))
+
+	// a request with credentials is still rejected when none are configured
+	require.ErrorIs(t, Postmark{}.Verify(req, nil), ErrInvalidSignature)
+}
+
+func TestPostmarkParse(t *testing.T) {
+	events, err := Postmark{}.Parse(fixture(t, "postmark_bounce.json"))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	assert.Equal(t, DeliveryEvent{
+		Provider:  "postmark",
+		Type:      EventBounced,
+		Email:     "bounced@example.com",
+		MessageID: "883953f4-6105-42a2-a16a-77a8eac79483",
+		Timestamp: time.Date(2026, time.March, 14, 15, 9, 26, 0, time.UTC),
+		Permanent: true,
+		Reason:    "The server was unable to deliver your message (ex: unknown user, mailbox not found).",
+		Tags:      map[string]string{"category": "security", "tag": "password_reset_request"},
+	}, events[0])
+
+	events, err = Postmark{}.Parse(fixture(t, "postmark_open.json"))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventOpened, events[0].Type)
+	assert.Equal(t, "reader@example.com", events[0].Email)
+	assert.Equal(t, "welcome", events[0].Template())
+}
@@ -0,0 +1,133 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of the svix signatures used by Resend
+const (
+	svixIDHeader        = "svix-id"
+	svixTimestampHeader = "svix-timestamp"
+	svixSignatureHeader = "svix-signature"
+
+	// svixSecretPrefix is the prefix of the signing secrets shown in the Resend dashboard
+	svixSecretPrefix = "whsec_"
+	// svixSignatureVersion is the version prefix of each signature in the signature header
+	svixSignatureVersion = "v1,"
+)
+
+// Resend parses the webhooks of Resend, which are signed with svix
+type Resend struct {
+	// Secret is the signing secret of the webhook, e.g. whsec_..., every request is rejected when empty
+	Secret string
+}
+
+// resendEvent is the payload of a Resend webhook
+type resendEvent struct {
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      struct {
+		EmailID string            `json:"email_id"`
+		To      []string          `json:"to"`
+		Tags    map[string]string `json:"tags"`
+		Bounce  struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"bounce"`
+		Click struct {
+			Link string `json:"link"`
+		} `json:"click"`
+	} `json:"data"`
+}
+
+// resendEventTypes are the Resend events that are parsed
+var resendEventTypes = map[string]EventType{
+	"email.delivered":  EventDelivered,
+	"email.bounced":    EventBounced,
+	"email.complained": EventComplained,
+	"email.opened":     EventOpened,
+	"email.clicked":    EventClicked,
+}
+
+// Name of the provider
+func (Resend) Name() string {
+	return "resend"
+}
+
+// Verify checks one of the svix signatures of the request matches the id, timestamp and body
+func (p Resend) Verify(r *http.Request, body []byte) error {
+	if p.Secret == "" {
+		return fmt.Errorf("%w: no webhook secret configured", ErrInvalidSignature)
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(p.Secret, svixSecretPrefix))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	id := r.Header.Get(svixIDHeader)
+	timestamp := r.Header.Get(svixTimestampHeader)
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || id == "" {
+		return ErrInvalidSignature
+	}
+
+	if err := checkTimestamp(time.Unix(seconds, 0)); err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	// the header holds space separated signatures, one per active secret while it is rotated
+	for _, sig := range strings.Fields(r.Header.Get(svixSignatureHeader)) {
+		if v, ok := strings.CutPrefix(sig, svixSignatureVersion); ok && hmac.Equal([]byte(v), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// Parse returns an event for each recipient of the message
+func (p Resend) Parse(body []byte) ([]DeliveryEvent, error) {
+	var e resendEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	eventType, ok := resendEventTypes[e.Type]
+	if !ok {
+		return nil, nil
+	}
+
+	events := make([]DeliveryEvent, 0, len(e.Data.To))
+
+	for _, to := range e.Data.To {
+		events = append(events, DeliveryEvent{
+			Provider:  p.Name(),
+			Type:      eventType,
+			Email:     to,
+			MessageID: e.Data.EmailID,
+			Timestamp: e.CreatedAt,
+			Permanent: e.Data.Bounce.Type == "Permanent",
+			Reason:    e.Data.Bounce.Message,
+			URL:       e.Data.Click.Link,
+			Tags:      e.Data.Tags,
+		})
+	}
+
+	return events, nil
+}
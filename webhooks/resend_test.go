@@ -0,0 +1,76 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resendSecret is the svix signing secret used in the tests
+var resendSecret = "whsec_" + base64.StdEncoding.EncodeToString([]byte("resend-test-secret"))
+
+// signResend returns a request for the body signed the way svix signs it
+func signResend(t *testing.T, body []byte, at time.Time) *http.Request {
+	t.Helper()
+
+	id := "msg_2LJ8XyB2Qv1Z"
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte("resend-test-secret"))
+	mac.Write([]byte(id + "." + timestamp + "." + string(body)))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/resend", strings.NewReader(string(body)))
+	req.Header.Set(svixIDHeader, id)
+	req.Header.Set(svixTimestampHeader, timestamp)
+	req.Header.Set(svixSignatureHeader, "v1,b2xkLXNpZ25hdHVyZQ== v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+	return req
+}
+
+func TestResendVerify(t *testing.T) {
+	body := fixture(t, "resend_bounced.json")
+	provider := Resend{Secret: resendSecret}
+
+	require.NoError(t, provider.Verify(signResend(t, body, time.Now()), body))
+	require.ErrorIs(t, provider.Verify(signResend(t, body, time.Now().Add(-time.Hour)), body), ErrTimestampOutOfRange)
+	require.ErrorIs(t, provider.Verify(signResend(t, body, time.Now()), append(body, ' ')), ErrInvalidSignature)
+	require.ErrorIs(t, Resend{Secret: "whsec_" + base64.StdEncoding.EncodeToString([]byte("other"))}.Verify(signResend(t, body, time.Now()), body), ErrInvalidSignature)
+	require.ErrorIs(t, Resend{}.Verify(signResend(t, body, time.Now()), body), ErrInvalidSignature)
+}
+
+func TestResendParse(t *testing.T) {
+	events, err := Resend{}.Parse(fixture(t, "resend_bounced.json"))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	assert.Equal(t, DeliveryEvent{
+		Provider:  "resend",
+		Type:      EventBounced,
+		Email:     "bounced@example.com",
+		MessageID: "56761188-7520-42d8-8898-ff6fc54ce618",
+		Timestamp: time.Date(2026, time.March, 14, 15, 9, 26, 0, time.UTC),
+		Permanent: true,
+		Reason:    "The recipient's email provider sent a hard bounce message.",
+		Tags:      map[string]string{"template": "subscribe", "category": "marketing"},
+	}, events[0])
+	assert.Equal(t, "subscribe", events[0].Template())
+
+	events, err = Resend{}.Parse(fixture(t, "resend_clicked.json"))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventClicked, events[0].Type)
+	assert.Equal(t, "https://docs.theopenlane.io", events[0].URL)
+
+	events, err = Resend{}.Parse([]byte(`{"type":"email.sent","data":{"to":["a@example.com"]}}`))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
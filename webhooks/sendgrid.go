@@ -0,0 +1,167 @@
+package webhooks
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of the signed event webhook of SendGrid
+const (
+	sendGridSignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	sendGridTimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// SendGrid parses the event webhooks of SendGrid
+type SendGrid struct {
+	// PublicKey is the base64 verification key shown when the signed event webhook is enabled
+	PublicKey string
+}
+
+// sendGridEventTypes are the SendGrid events that are parsed
+var sendGridEventTypes = map[string]EventType{
+	"delivered":  EventDelivered,
+	"bounce":     EventBounced,
+	"spamreport": EventComplained,
+	"open":       EventOpened,
+	"click":      EventClicked,
+}
+
+// sendGridFields are the fields of an event that are not custom arguments
+var sendGridFields = map[string]bool{
+	"email": true, "timestamp": true, "event": true, "sg_event_id": true, "sg_message_id": true,
+	"smtp-id": true, "reason": true, "status": true, "type": true, "url": true, "url_offset": true,
+	"useragent": true, "ip": true, "response": true, "attempt": true, "tls": true, "cert_err": true,
+	"category": true, "asm_group_id": true, "bounce_classification": true, "sg_machine_open": true,
+}
+
+// sendGridEvent is an event in the payload of a SendGrid webhook
+type sendGridEvent struct {
+	Email       string `json:"email"`
+	Timestamp   int64  `json:"timestamp"`
+	Event       string `json:"event"`
+	SGMessageID string `json:"sg_message_id"`
+	Reason      string `json:"reason"`
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+}
+
+// Name of the provider
+func (SendGrid) Name() string {
+	return "sendgrid"
+}
+
+// Verify checks the ECDSA signature of the timestamp and body
+func (p SendGrid) Verify(r *http.Request, body []byte) error {
+	der, err := base64.StdEncoding.DecodeString(p.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	timestamp := r.Header.Get(sendGridTimestampHeader)
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if err := checkTimestamp(time.Unix(seconds, 0)); err != nil {
+		return err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get(sendGridSignatureHeader))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(timestamp))
+	hash.Write(body)
+
+	if !ecdsa.VerifyASN1(key, hash.Sum(nil), sig) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Parse returns the events in the payload, custom arguments are returned as the tags
+func (p SendGrid) Parse(body []byte) ([]DeliveryEvent, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	events := []DeliveryEvent{}
+
+	for _, data := range raw {
+		var (
+			e      sendGridEvent
+			fields map[string]json.RawMessage
+		)
+
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+		}
+
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+		}
+
+		eventType, ok := sendGridEventTypes[e.Event]
+		if !ok {
+			continue
+		}
+
+		events = append(events, DeliveryEvent{
+			Provider:  p.Name(),
+			Type:      eventType,
+			Email:     e.Email,
+			MessageID: e.SGMessageID,
+			Timestamp: time.Unix(e.Timestamp, 0).UTC(),
+			// blocked bounces are temporary, e.g. the recipient server rejected the message as spam
+			Permanent: e.Event == "bounce" && e.Type != "blocked",
+			Reason:    e.Reason,
+			URL:       e.URL,
+			Tags:      customArgs(fields),
+		})
+	}
+
+	return events, nil
+}
+
+// customArgs returns the string fields of the event that are not SendGrid fields
+func customArgs(fields map[string]json.RawMessage) map[string]string {
+	var tags map[string]string
+
+	for k, v := range fields {
+		var s string
+		if sendGridFields[k] || json.Unmarshal(v, &s) != nil {
+			continue
+		}
+
+		if tags == nil {
+			tags = map[string]string{}
+		}
+
+		tags[k] = s
+	}
+
+	return tags
+}
@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signSendGrid returns a request for the body signed with the key at the time
+func signSendGrid(t *testing.T, key *ecdsa.PrivateKey, body []byte, at time.Time) *http.Request {
+	t.Helper()
+
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	hash := sha256.Sum256([]byte(timestamp + string(body)))
+
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/sendgrid", strings.NewReader(string(body)))
+	req.Header.Set(sendGridTimestampHeader, timestamp)
+	req.Header.Set(sendGridSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+
+	return req
+}
+
+func TestSendGridVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	provider := SendGrid{PublicKey: base64.StdEncoding.EncodeToString(der)}
+	body := fixture(t, "sendgrid_events.json")
+
+	require.NoError(t, provider.Verify(signSendGrid(t, key, body, time.Now()), body))
+	require.ErrorIs(t, provider.Verify(signSendGrid(t, key, body, time.Now()), append(body, ' ')), ErrInvalidSignature)
+	require.ErrorIs(t, provider.Verify(signSendGrid(t, key, body, time.Now().Add(time.Hour)), body), ErrTimestampOutOfRange)
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	require.ErrorIs(t, provider.Verify(signSendGrid(t, other, body, time.Now()), body), ErrInvalidSignature)
+}
+
+func TestSendGridParse(t *testing.T) {
+	events, err := SendGrid{}.Parse(fixture(t, "sendgrid_events.json"))
+	require.NoError(t, err)
+
+	// the processed event is skipped
+	require.Len(t, events, 5)
+
+	assert.Equal(t, EventDelivered, events[0].Type)
+	assert.Equal(t, map[string]string{"template": "welcome"}, events[0].Tags)
+
+	assert.Equal(t, EventBounced, events[1].Type)
+	assert.True(t, events[1].Permanent)
+	assert.Equal(t, "500 unknown recipient", events[1].Reason)
+	assert.Equal(t, "subscribe", events[1].Template())
+
+	assert.Equal(t, EventBounced, events[2].Type)
+	assert.False(t, events[2].Permanent, "blocked bounces are temporary")
+
+	assert.Equal(t, EventComplained, events[3].Type)
+	assert.Equal(t, "spam@example.com", events[3].Email)
+
+	assert.Equal(t, EventClicked, events[4].Type)
+	assert.Equal(t, "https://docs.theopenlane.io", events[4].URL)
+	assert.Equal(t, int64(1773500970), events[4].Timestamp.Unix())
+}
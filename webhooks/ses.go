@@ -0,0 +1,362 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Types of SNS messages
+const (
+	snsNotification             = "Notification"
+	snsSubscriptionConfirmation = "SubscriptionConfirmation"
+
+	// snsSignatureVersion is the SHA256 signature version, topics must be configured to use it
+	snsSignatureVersion = "2"
+
+	// snsTimestampLayout is the format of the timestamp of an SNS message, e.g. 2026-03-14T15:09:27.000Z
+	snsTimestampLayout = "2006-01-02T15:04:05.000Z"
+)
+
+var (
+	// snsCertHostPattern matches the hosts SNS serves its signing certificates from
+	snsCertHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+	// snsCertificates caches the signing certificates by URL
+	snsCertificates sync.Map
+)
+
+// SES parses the SES events published to an SNS topic with an https subscription
+type SES struct {
+	// TopicARNs are the topics messages are accepted from, at least one is required as any SNS topic
+	// can sign a message, every request is rejected when empty
+	TopicARNs []string
+	// HTTPClient fetches the SNS signing certificates, http.DefaultClient is used when nil
+	HTTPClient *http.Client
+	// FetchCertificate replaces fetching the signing certificate with the HTTPClient, e.g. in tests
+	FetchCertificate func(ctx context.Context, certURL string) (*x509.Certificate, error)
+}
+
+// snsMessage is the payload of an SNS https notification
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// sesRecipient is a recipient of a bounce or complaint
+type sesRecipient struct {
+	EmailAddress   string `json:"emailAddress"`
+	DiagnosticCode string `json:"diagnosticCode"`
+}
+
+// sesNotification is an SES event, either from an event publishing configuration set or a
+// notification topic of the identity
+type sesNotification struct {
+	EventType        string `json:"eventType"`
+	NotificationType string `json:"notificationType"`
+	Mail             struct {
+		MessageID   string              `json:"messageId"`
+		Timestamp   time.Time           `json:"timestamp"`
+		Destination []string            `json:"destination"`
+		Tags        map[string][]string `json:"tags"`
+	} `json:"mail"`
+	Bounce struct {
+		BounceType        string         `json:"bounceType"`
+		BouncedRecipients []sesRecipient `json:"bouncedRecipients"`
+		Timestamp         time.Time      `json:"timestamp"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplainedRecipients  []sesRecipient `json:"complainedRecipients"`
+		ComplaintFeedbackType string         `json:"complaintFeedbackType"`
+		Timestamp             time.Time      `json:"timestamp"`
+	} `json:"complaint"`
+	Delivery struct {
+		Recipients []string  `json:"recipients"`
+		Timestamp  time.Time `json:"timestamp"`
+	} `json:"delivery"`
+	Open struct {
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"open"`
+	Click struct {
+		Link      string    `json:"link"`
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"click"`
+}
+
+// Name of the provider
+func (SES) Name() string {
+	return "ses"
+}
+
+// Verify checks the topic, the SNS signature of the message with the certificate it references and that the
+// message was sent within the timestamp tolerance
+func (p SES) Verify(r *http.Request, body []byte) error {
+	var m snsMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	if len(p.TopicARNs) == 0 {
+		return fmt.Errorf("%w: no topic arns configured", ErrInvalidSignature)
+	}
+
+	if !slices.Contains(p.TopicARNs, m.TopicArn) {
+		return fmt.Errorf("%w: unexpected topic %q", ErrInvalidSignature, m.TopicArn)
+	}
+
+	if m.SignatureVersion != snsSignatureVersion {
+		return ErrUnsupportedSignatureVersion
+	}
+
+	if err := checkCertificateURL(m.SigningCertURL); err != nil {
+		return err
+	}
+
+	cert, err := p.certificate(r.Context(), m.SigningCertURL)
+	if err != nil {
+		return err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if err := cert.CheckSignature(x509.SHA256WithRSA, []byte(m.stringToSign()), sig); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	timestamp, err := time.Parse(snsTimestampLayout, m.Timestamp)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	return checkTimestamp(timestamp)
+}
+
+// Parse returns an event for each recipient of the SES notification, subscription confirmations are
+// logged with the URL to confirm them and have no events
+func (p SES) Parse(body []byte) ([]DeliveryEvent, error) {
+	var m snsMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	if m.Type == snsSubscriptionConfirmation {
+		log.Info().Str("topic", m.TopicArn).Str("subscribe_url", m.SubscribeURL).Msg("confirm the sns subscription")
+	}
+
+	if m.Type != snsNotification {
+		return nil, nil
+	}
+
+	var n sesNotification
+	if err := json.Unmarshal([]byte(m.Message), &n); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	base := DeliveryEvent{
+		Provider:  p.Name(),
+		MessageID: n.Mail.MessageID,
+		Tags:      firstValues(n.Mail.Tags),
+	}
+
+	var recipients []sesRecipient
+
+	switch valueOr(n.EventType, n.NotificationType) {
+	case "Delivery":
+		base.Type = EventDelivered
+		base.Timestamp = n.Delivery.Timestamp
+		recipients = addresses(n.Delivery.Recipients)
+	case "Bounce":
+		base.Type = EventBounced
+		base.Timestamp = n.Bounce.Timestamp
+		base.Permanent = n.Bounce.BounceType == "Permanent"
+		recipients = n.Bounce.BouncedRecipients
+	case "Complaint":
+		base.Type = EventComplained
+		base.Timestamp = n.Complaint.Timestamp
+		base.Reason = n.Complaint.ComplaintFeedbackType
+		recipients = n.Complaint.ComplainedRecipients
+	case "Open":
+		base.Type = EventOpened
+		base.Timestamp = n.Open.Timestamp
+		recipients = addresses(n.Mail.Destination)
+	case "Click":
+		base.Type = EventClicked
+		base.Timestamp = n.Click.Timestamp
+		base.URL = n.Click.Link
+		recipients = addresses(n.Mail.Destination)
+	default:
+		return nil, nil
+	}
+
+	events := make([]DeliveryEvent, 0, len(recipients))
+
+	for _, recipient := range recipients {
+		event := base
+		event.Email = recipient.EmailAddress
+
+		if recipient.DiagnosticCode != "" {
+			event.Reason = recipient.DiagnosticCode
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// stringToSign returns the fields of the message in the order SNS signs them
+func (m snsMessage) stringToSign() string {
+	fields := [][2]string{{"Message", m.Message}, {"MessageId", m.MessageID}}
+
+	if m.Type == snsNotification {
+		if m.Subject != "" {
+			fields = append(fields, [2]string{"Subject", m.Subject})
+		}
+	} else {
+		fields = append(fields, [2]string{"SubscribeURL", m.SubscribeURL})
+	}
+
+	fields = append(fields, [2]string{"Timestamp", m.Timestamp})
+
+	if m.Type != snsNotification {
+		fields = append(fields, [2]string{"Token", m.Token})
+	}
+
+	fields = append(fields, [2]string{"TopicArn", m.TopicArn}, [2]string{"Type", m.Type})
+
+	b := strings.Builder{}
+
+	for _, f := range fields {
+		b.WriteString(f[0] + "\n" + f[1] + "\n")
+	}
+
+	return b.String()
+}
+
+// checkCertificateURL ensures the certificate is served by SNS over https
+func checkCertificateURL(certURL string) error {
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || !snsCertHostPattern.MatchString(u.Hostname()) || !strings.HasSuffix(u.Path, ".pem") {
+		return ErrInvalidCertificateURL
+	}
+
+	return nil
+}
+
+// certificate returns the signing certificate, fetched once per URL
+func (p SES) certificate(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	if p.FetchCertificate != nil {
+		return p.FetchCertificate(ctx, certURL)
+	}
+
+	if cert, ok := snsCertificates.Load(certURL); ok {
+		return cert.(*x509.Certificate), nil
+	}
+
+	cert, err := p.fetchCertificate(ctx, certURL)
+	if err != nil {
+		return nil, err
+	}
+
+	snsCertificates.Store(certURL, cert)
+
+	return cert, nil
+}
+
+// fetchCertificate downloads and parses the pem encoded certificate
+func (p SES) fetchCertificate(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: fetching certificate returned %s", ErrInvalidCertificateURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no pem certificate", ErrInvalidCertificateURL)
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// addresses returns the addresses as recipients
+func addresses(emails []string) []sesRecipient {
+	recipients := make([]sesRecipient, 0, len(emails))
+
+	for _, email := range emails {
+		recipients = append(recipients, sesRecipient{EmailAddress: email})
+	}
+
+	return recipients
+}
+
+// firstValues returns the first value of each tag, nil for no tags
+func firstValues(m map[string][]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(m))
+
+	for k, v := range m {
+		if len(v) > 0 {
+			out[k] = v[0]
+		}
+	}
+
+	return out
+}
+
+// valueOr returns the value, or the fallback when it is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,151 @@
+package webhooks
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snsSigner signs SNS messages with a self signed certificate
+type snsSigner struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+// newSNSSigner returns a signer with a new key and certificate
+func newSNSSigner(t *testing.T) snsSigner {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return snsSigner{key: key, cert: cert}
+}
+
+// sign returns the fixture with its timestamp and signature replaced by one made with the signer at the time
+func (s snsSigner) sign(t *testing.T, body []byte, at time.Time) []byte {
+	t.Helper()
+
+	var m snsMessage
+	require.NoError(t, json.Unmarshal(body, &m))
+
+	m.Timestamp = at.UTC().Format(snsTimestampLayout)
+
+	hash := sha256.Sum256([]byte(m.stringToSign()))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash[:])
+	require.NoError(t, err)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(body, &payload))
+
+	payload["Timestamp"] = m.Timestamp
+	payload["Signature"] = base64.StdEncoding.EncodeToString(sig)
+
+	signed, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	return signed
+}
+
+// sesTopicARN is the topic of the SES fixtures
+const sesTopicARN = "arn:aws:sns:us-west-2:123456789012:ses-events"
+
+// provider returns an SES provider that uses the signer's certificate and accepts the topics
+func (s snsSigner) provider(topics ...string) SES {
+	return SES{
+		TopicARNs: topics,
+		FetchCertificate: func(context.Context, string) (*x509.Certificate, error) {
+			return s.cert, nil
+		},
+	}
+}
+
+func TestSESVerify(t *testing.T) {
+	signer := newSNSSigner(t)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/ses", nil)
+
+	for _, name := range []string{"ses_bounce.json", "ses_complaint.json", "ses_subscription_confirmation.json"} {
+		body := signer.sign(t, fixture(t, name), time.Now())
+		require.NoError(t, signer.provider(sesTopicARN).Verify(req, body), name)
+	}
+
+	body := signer.sign(t, fixture(t, "ses_bounce.json"), time.Now())
+
+	require.ErrorIs(t, signer.provider().Verify(req, body), ErrInvalidSignature)
+	require.ErrorIs(t, signer.provider("arn:aws:sns:us-west-2:123456789012:other").Verify(req, body), ErrInvalidSignature)
+	require.ErrorIs(t, newSNSSigner(t).provider(sesTopicARN).Verify(req, body), ErrInvalidSignature)
+	require.ErrorIs(t, signer.provider(sesTopicARN).Verify(req, fixture(t, "ses_bounce.json")), ErrInvalidSignature)
+
+	// a captured notification cannot be replayed
+	replayed := signer.sign(t, fixture(t, "ses_bounce.json"), time.Now().Add(-time.Hour))
+	require.ErrorIs(t, signer.provider(sesTopicARN).Verify(req, replayed), ErrTimestampOutOfRange)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(body, &payload))
+
+	payload["SigningCertURL"] = "https://attacker.example.com/cert.pem"
+	forged, err := json.Marshal(payload)
+	require.NoError(t, err)
+	require.ErrorIs(t, signer.provider(sesTopicARN).Verify(req, forged), ErrInvalidCertificateURL)
+
+	payload["SignatureVersion"] = "1"
+	forged, err = json.Marshal(payload)
+	require.NoError(t, err)
+	require.ErrorIs(t, signer.provider(sesTopicARN).Verify(req, forged), ErrUnsupportedSignatureVersion)
+}
+
+func TestSESParse(t *testing.T) {
+	events, err := SES{}.Parse(fixture(t, "ses_bounce.json"))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	assert.Equal(t, DeliveryEvent{
+		Provider:  "ses",
+		Type:      EventBounced,
+		Email:     "bounced@example.com",
+		MessageID: "0102017b2c8f0f5c-ses",
+		Timestamp: time.Date(2026, time.March, 14, 15, 9, 26, 0, time.UTC),
+		Permanent: true,
+		Reason:    "smtp; 550 5.1.1 user unknown",
+		Tags:      map[string]string{"template": "invite", "category": "transactional"},
+	}, events[0])
+
+	events, err = SES{}.Parse(fixture(t, "ses_complaint.json"))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventComplained, events[0].Type)
+	assert.Equal(t, "spam@example.com", events[0].Email)
+	assert.Equal(t, "abuse", events[0].Reason)
+
+	events, err = SES{}.Parse(fixture(t, "ses_subscription_confirmation.json"))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
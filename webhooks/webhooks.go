@@ -0,0 +1,171 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/theopenlane/emailtemplates"
+)
+
+const (
+	// maxBodyBytes is the largest webhook payload that is read
+	maxBodyBytes = 1 << 20
+
+	// timestampTolerance is how far the timestamp of a signed request may be from now, to prevent replays
+	timestampTolerance = 5 * time.Minute
+)
+
+// EventType is the kind of delivery event
+type EventType string
+
+// Types of the delivery events
+const (
+	EventDelivered  EventType = "delivered"
+	EventBounced    EventType = "bounced"
+	EventComplained EventType = "complained"
+	EventOpened     EventType = "opened"
+	EventClicked    EventType = "clicked"
+)
+
+// DeliveryEvent is a delivery, bounce, complaint, open or click of an email reported by a provider
+type DeliveryEvent struct {
+	// Provider is the name of the provider that reported the event
+	Provider string
+	// Type of the event
+	Type EventType
+	// Email is the recipient the event is about
+	Email string
+	// MessageID is the ID the provider assigned to the message
+	MessageID string
+	// Timestamp is when the event happened
+	Timestamp time.Time
+	// Permanent is set for bounces that will not succeed on retry, e.g. the mailbox does not exist
+	Permanent bool
+	// Reason is the bounce or complaint reason given by the provider
+	Reason string
+	// URL is the link that was clicked
+	URL string
+	// Tags are the tags or metadata sent with the message, e.g. the template, category and message_id tags
+	Tags map[string]string
+}
+
+// Template returns the template the email was rendered from, when the provider reports the tags
+func (e DeliveryEvent) Template() string {
+	return e.Tags[emailtemplates.TemplateTag]
+}
+
+// Provider verifies and parses the webhook requests of an email provider
+type Provider interface {
+	// Name of the provider, set on the events
+	Name() string
+	// Verify checks the signature of the request, the body has already been read
+	Verify(r *http.Request, body []byte) error
+	// Parse returns the events in the body, events of unknown types are skipped
+	Parse(body []byte) ([]DeliveryEvent, error)
+}
+
+// Sink receives the parsed events
+type Sink interface {
+	// HandleEvent handles the event, an error makes the handler respond with a server error so the
+	// provider retries
+	HandleEvent(ctx context.Context, event DeliveryEvent) error
+}
+
+// SinkFunc is an adapter to use a function as a Sink
+type SinkFunc func(ctx context.Context, event DeliveryEvent) error
+
+// HandleEvent calls f(ctx, event)
+func (f SinkFunc) HandleEvent(ctx context.Context, event DeliveryEvent) error {
+	return f(ctx, event)
+}
+
+// Handler verifies and parses the webhook requests of a provider and passes the events to the sink
+type Handler struct {
+	provider Provider
+	sink     Sink
+}
+
+// NewHandler returns a handler for the webhooks of the provider, serve it on the URL configured with
+// the provider
+func NewHandler(provider Provider, sink Sink) *Handler {
+	return &Handler{provider: provider, sink: sink}
+}
+
+// ServeHTTP verifies the request and passes its events to the sink, requests with an invalid
+// signature are rejected and sink errors are reported so the provider retries
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.provider.Verify(r, body); err != nil {
+		log.Warn().Err(err).Str("provider", h.provider.Name()).Msg("rejected webhook")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+
+		return
+	}
+
+	events, err := h.provider.Parse(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, event := range events {
+		if err := h.sink.HandleEvent(r.Context(), event); err != nil {
+			log.Error().Err(err).Str("provider", event.Provider).Str("type", string(event.Type)).Msg("could not handle webhook event")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// NewSuppressionSink returns a sink that adds recipients to the store when their email permanently
+// bounced or they complained, other events are ignored
+func NewSuppressionSink(store emailtemplates.SuppressionStore) Sink {
+	return SinkFunc(func(ctx context.Context, event DeliveryEvent) error {
+		var reason emailtemplates.SuppressionReason
+
+		switch {
+		case event.Email == "":
+			return nil
+		case event.Type == EventBounced && event.Permanent:
+			reason = emailtemplates.SuppressionBounce
+		case event.Type == EventComplained:
+			reason = emailtemplates.SuppressionComplaint
+		default:
+			return nil
+		}
+
+		return store.Suppress(ctx, emailtemplates.Suppression{
+			Email:     event.Email,
+			Reason:    reason,
+			CreatedAt: event.Timestamp,
+		})
+	})
+}
+
+// checkTimestamp returns ErrTimestampOutOfRange when the time is outside the tolerance
+func checkTimestamp(t time.Time) error {
+	if d := time.Since(t); d > timestampTolerance || d < -timestampTolerance {
+		return ErrTimestampOutOfRange
+	}
+
+	return nil
+}
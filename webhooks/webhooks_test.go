@@ -0,0 +1,121 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/theopenlane/emailtemplates"
+)
+
+// errSink is returned by the failing sink in the tests
+var errSink = errors.New("sink failed")
+
+// fixture returns the recorded payload in testdata
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	return data
+}
+
+// collect returns a sink that appends the events to the slice
+func collect(events *[]DeliveryEvent) Sink {
+	return SinkFunc(func(_ context.Context, event DeliveryEvent) error {
+		*events = append(*events, event)
+
+		return nil
+	})
+}
+
+func TestHandler(t *testing.T) {
+	body := fixture(t, "postmark_bounce.json")
+	provider := Postmark{Username: "hook", Password: "secret"}
+
+	var events []DeliveryEvent
+
+	handler := NewHandler(provider, collect(&events))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/postmark", strings.NewReader(string(body)))
+	req.SetBasicAuth("hook", "secret")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	require.Len(t, events, 1)
+	assert.Equal(t, "password_reset_request", events[0].Tags["tag"])
+
+	// requests with invalid credentials are rejected
+	req = httptest.NewRequest(http.MethodPost, "/webhooks/postmark", strings.NewReader(string(body)))
+	req.SetBasicAuth("hook", "wrong")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Len(t, events, 1)
+
+	// only posts are accepted
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/webhooks/postmark", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	// requests are rejected when no credentials are configured
+	req = httptest.NewRequest(http.MethodPost, "/webhooks/postmark", strings.NewReader(string(body)))
+
+	rec = httptest.NewRecorder()
+	NewHandler(Postmark{}, collect(&events)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Len(t, events, 1)
+
+	// invalid payloads are rejected
+	req = httptest.NewRequest(http.MethodPost, "/webhooks/postmark", strings.NewReader("{"))
+	req.SetBasicAuth("hook", "secret")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// sink errors are reported so the provider retries
+	failing := NewHandler(provider, SinkFunc(func(context.Context, DeliveryEvent) error { return errSink }))
+
+	req = httptest.NewRequest(http.MethodPost, "/webhooks/postmark", strings.NewReader(string(body)))
+	req.SetBasicAuth("hook", "secret")
+
+	rec = httptest.NewRecorder()
+	failing.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestSuppressionSink(t *testing.T) {
+	ctx := context.Background()
+	store := emailtemplates.NewMemorySuppressionStore()
+	sink := NewSuppressionSink(store)
+
+	require.NoError(t, sink.HandleEvent(ctx, DeliveryEvent{Type: EventBounced, Permanent: true, Email: "bounced@example.com"}))
+	require.NoError(t, sink.HandleEvent(ctx, DeliveryEvent{Type: EventBounced, Email: "soft@example.com"}))
+	require.NoError(t, sink.HandleEvent(ctx, DeliveryEvent{Type: EventComplained, Email: "spam@example.com"}))
+	require.NoError(t, sink.HandleEvent(ctx, DeliveryEvent{Type: EventOpened, Email: "reader@example.com"}))
+
+	list := store.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, "bounced@example.com", list[0].Email)
+	assert.Equal(t, emailtemplates.SuppressionBounce, list[0].Reason)
+	assert.Equal(t, "spam@example.com", list[1].Email)
+	assert.Equal(t, emailtemplates.SuppressionComplaint, list[1].Reason)
+}